@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gics-to-kafka/pkg/check"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/journal"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/web"
+	"os"
+)
+
+// runCommand runs a maintenance command and returns its exit code
+func runCommand(name string, args []string, configPath string) int {
+	switch name {
+	case "verify-journal":
+		return verifyJournal(args, configPath)
+	case "export-journal":
+		return exportJournal(args, configPath)
+	case "check":
+		return checkConfig(configPath)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+		fmt.Fprintln(os.Stderr, "Usage: gics-to-kafka [--config path] [check | verify-journal [path] | export-journal [path]]")
+		return 2
+	}
+}
+
+// journalPath returns the journal path argument or the configured path
+func journalPath(args []string, configPath string) (string, bool) {
+	if len(args) > 0 {
+		return args[0], true
+	}
+	c := config.LoadConfig(configPath)
+	if c == nil {
+		return "", false
+	}
+	return c.Journal.Path, true
+}
+
+func verifyJournal(args []string, configPath string) int {
+	path, ok := journalPath(args, configPath)
+	if !ok {
+		return 1
+	}
+
+	count, err := journal.Verify(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Journal verification failed after %d entries: %v\n", count, err)
+		return 1
+	}
+	fmt.Printf("Journal verified: %d entries\n", count)
+	return 0
+}
+
+// exportJournal writes the journal as CSV to stdout
+func exportJournal(args []string, configPath string) int {
+	path, ok := journalPath(args, configPath)
+	if !ok {
+		return 1
+	}
+
+	if err := journal.Export(path, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Journal export failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// checkConfig validates the configuration and the Kafka topics and exits
+// non-zero on failure, e.g. as a deployment gate
+func checkConfig(configPath string) int {
+	c := config.LoadConfig(configPath)
+	if c == nil {
+		fmt.Println("FAIL  configuration file")
+		return 1
+	}
+
+	report := check.Settings(*c)
+	report = append(report, check.Result{Name: "application settings", Err: web.Validate(*c)})
+	report = append(report, check.Files(*c)...)
+	report = append(report, check.Kafka(context.Background(), *c, func(k config.Kafka) (check.TopicChecker, error) {
+		return kafka.NewTopicChecker(k)
+	})...)
+	report.Print(os.Stdout)
+
+	if report.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
@@ -1,18 +1,39 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"gics-to-kafka/pkg/check"
 	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/tracing"
 	"gics-to-kafka/pkg/web"
+	"log/slog"
 	"os"
 )
 
 func main() {
+	configPath := flag.String("config", "", "config file or directory with app.yml (default $"+config.EnvConfigPath+" or working directory)")
+	flag.Parse()
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Arg(0), flag.Args()[1:], *configPath))
+	}
 
-	appConfig := config.LoadConfig(".")
+	appConfig := config.LoadConfig(*configPath)
 	if appConfig == nil {
 		os.Exit(1)
 	}
 	config.ConfigureLogger(appConfig.App)
+	if err := check.Required(*appConfig); err != nil {
+		slog.Error("Missing required settings", "error", err)
+		os.Exit(1)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), appConfig.App)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() { _ = shutdown(context.Background()) }()
 
 	server := web.NewServer(*appConfig)
 	server.Run()
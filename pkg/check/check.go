@@ -0,0 +1,296 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/envelope"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/signing"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const kafkaTimeout = 10 * time.Second
+
+// Result of a single check, passed if Err is nil
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Report lists the results of all checks
+type Report []Result
+
+// Failed returns the number of failed checks
+func (r Report) Failed() int {
+	n := 0
+	for _, res := range r {
+		if res.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Print writes a pass/fail line per check and a summary
+func (r Report) Print(w io.Writer) {
+	for _, res := range r {
+		if res.Err == nil {
+			_, _ = fmt.Fprintf(w, "PASS  %s\n", res.Name)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "FAIL  %s\n", res.Name)
+		for _, line := range strings.Split(res.Err.Error(), "\n") {
+			_, _ = fmt.Fprintf(w, "      %s\n", line)
+		}
+	}
+
+	if failed := r.Failed(); failed > 0 {
+		_, _ = fmt.Fprintf(w, "Check failed: %d of %d checks failed\n", failed, len(r))
+	} else {
+		_, _ = fmt.Fprintf(w, "Check passed: %d checks\n", len(r))
+	}
+}
+
+// TopicChecker verifies that the Kafka topics exist and may be written to
+type TopicChecker interface {
+	Check(ctx context.Context, topics []string) (map[string]error, error)
+	Close()
+}
+
+// Settings checks that required settings are present and formats are valid
+func Settings(c config.AppConfig) Report {
+	return Report{
+		{"required settings", Required(c)},
+		{"setting formats", formats(c)},
+	}
+}
+
+// Required returns an error for each required setting which is not set
+func Required(c config.AppConfig) error {
+	type setting struct {
+		name, value string
+		required    bool
+	}
+	settings := []setting{
+		{"app.http.port", c.App.Http.Port, true},
+		{"app.http.auth.user", c.App.Http.Auth.User, true},
+		{"app.http.auth.password", c.App.Http.Auth.Password, true},
+		{"app.http.admin.auth.user", c.App.Http.Admin.Auth.User, c.App.Http.Admin.Enabled},
+		{"app.http.admin.auth.password", c.App.Http.Admin.Auth.Password, c.App.Http.Admin.Enabled},
+		{"app.tracing.endpoint", c.App.Tracing.Endpoint, c.App.Tracing.Enabled},
+		{"kafka.bootstrap-servers", c.Kafka.BootstrapServers, true},
+		{"kafka.output-topic", c.Kafka.OutputTopic, true},
+		{"kafka.epix-topic", c.Kafka.EpixTopic, sendsToSourceTopic(c, notification.EPIX)},
+		{"kafka.gpas-topic", c.Kafka.GpasTopic, sendsToSourceTopic(c, notification.GPAS)},
+		{"kafka.dead-letter-topic", c.Kafka.DeadLetterTopic, c.App.UnknownTypes == config.UnknownTypesDeadLetter},
+		{"kafka.signing.key-id", c.Kafka.Signing.KeyId, c.Kafka.Signing.Enabled},
+		{"kafka.signing.key-file", c.Kafka.Signing.KeyFile, c.Kafka.Signing.Enabled},
+		{"kafka.encryption.key-id", c.Kafka.Encryption.KeyId, c.Kafka.Encryption.Enabled},
+		{"kafka.encryption.key-dir", c.Kafka.Encryption.KeyDir, c.Kafka.Encryption.Enabled},
+		{"store.path", c.Store.Path, c.Store.Enabled},
+		{"gics.url", c.Gics.Url, c.Enrichment.Enabled},
+		{"expiry.path", c.Expiry.Path, c.Expiry.Enabled},
+		{"journal.path", c.Journal.Path, c.Journal.Enabled},
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if s.required && s.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", s.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendsToSourceTopic reports whether records of the source may be sent to its
+// topic. Without clients, all sources are matched by client id prefix.
+// Otherwise, a configured client of the source must have no topic of its own.
+func sendsToSourceTopic(c config.AppConfig, src *notification.Source) bool {
+	if len(c.App.Clients) == 0 {
+		return true
+	}
+	for _, cl := range c.App.Clients {
+		s := notification.SourceOf(cl.Id)
+		if cl.Source != "" {
+			s = notification.SourceByName(cl.Source)
+		}
+		if s == src && cl.Topic == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func formats(c config.AppConfig) error {
+	var errs []error
+	if c.App.Http.Port != "" {
+		if err := validatePort(c.App.Http.Port); err != nil {
+			errs = append(errs, fmt.Errorf("app.http.port: %w", err))
+		}
+	}
+	for _, server := range strings.Split(c.Kafka.BootstrapServers, ",") {
+		if server == "" {
+			continue
+		}
+		if err := validateHostPort(strings.TrimSpace(server)); err != nil {
+			errs = append(errs, fmt.Errorf("kafka.bootstrap-servers: %w", err))
+		}
+	}
+	switch strings.ToLower(c.Kafka.SecurityProtocol) {
+	case "", "plaintext", "ssl", "sasl_plaintext", "sasl_ssl":
+	default:
+		errs = append(errs, fmt.Errorf("kafka.security-protocol: unknown protocol %s", c.Kafka.SecurityProtocol))
+	}
+	if c.Enrichment.Enabled && c.Gics.Url != "" {
+		if err := validateUrl(c.Gics.Url); err != nil {
+			errs = append(errs, fmt.Errorf("gics.url: %w", err))
+		}
+	}
+	if c.App.Tracing.Enabled && c.App.Tracing.Endpoint != "" {
+		if err := validateHostPort(c.App.Tracing.Endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("app.tracing.endpoint: %w", err))
+		}
+	}
+	if c.Expiry.Enabled && c.Expiry.Interval <= 0 {
+		errs = append(errs, errors.New("expiry.interval: must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+func validatePort(port string) error {
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return fmt.Errorf("invalid port %s", port)
+	}
+	return nil
+}
+
+func validateHostPort(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return fmt.Errorf("missing host in %s", address)
+	}
+	return validatePort(port)
+}
+
+func validateUrl(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid http url %s", raw)
+	}
+	return nil
+}
+
+// Files checks that the configured certificates and keys exist and are readable
+func Files(c config.AppConfig) Report {
+	var r Report
+	if strings.Contains(strings.ToLower(c.Kafka.SecurityProtocol), "ssl") {
+		var errs []error
+		for _, f := range []struct{ name, path string }{
+			{"kafka.ssl.ca-location", c.Kafka.Ssl.CaLocation},
+			{"kafka.ssl.certificate-location", c.Kafka.Ssl.CertificateLocation},
+			{"kafka.ssl.key-location", c.Kafka.Ssl.KeyLocation},
+		} {
+			if f.path == "" {
+				continue
+			}
+			if err := readable(f.path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+			}
+		}
+		r = append(r, Result{"ssl files", errors.Join(errs...)})
+	}
+	if s := c.Kafka.Signing; s.Enabled {
+		_, err := signing.LoadSigner(s.KeyId, s.KeyFile)
+		r = append(r, Result{"signing key", err})
+	}
+	if e := c.Kafka.Encryption; e.Enabled {
+		_, err := envelope.LoadLocalKMS(e.KeyId, e.KeyDir)
+		r = append(r, Result{"encryption keys", err})
+	}
+	return r
+}
+
+func readable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = f.Read(make([]byte, 1))
+	return err
+}
+
+// Kafka connects to the cluster and checks all topics records are sent to.
+// Creating the client verifies the SSL settings, including the key password.
+func Kafka(ctx context.Context, c config.AppConfig, connect func(config.Kafka) (TopicChecker, error)) Report {
+	checker, err := connect(c.Kafka)
+	if err != nil {
+		return Report{{"kafka client", err}}
+	}
+	defer checker.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, kafkaTimeout)
+	defer cancel()
+
+	topics := Topics(c)
+	errs, err := checker.Check(ctx, topics)
+	if err != nil {
+		err = fmt.Errorf("unable to reach %s: %w", c.Kafka.BootstrapServers, err)
+	}
+	r := Report{{"kafka client", nil}, {"kafka connection", err}}
+	if err != nil {
+		return r
+	}
+	for _, topic := range topics {
+		err, ok := errs[topic]
+		if !ok {
+			err = errors.New("topic not described by the cluster")
+		}
+		r = append(r, Result{"topic " + topic, err})
+	}
+	return r
+}
+
+// Topics returns all configured topics records are sent to
+func Topics(c config.AppConfig) []string {
+	candidates := []string{c.Kafka.OutputTopic}
+	if sendsToSourceTopic(c, notification.EPIX) {
+		candidates = append(candidates, c.Kafka.EpixTopic)
+	}
+	if sendsToSourceTopic(c, notification.GPAS) {
+		candidates = append(candidates, c.Kafka.GpasTopic)
+	}
+	candidates = append(candidates, c.Kafka.SnapshotTopic)
+	if c.App.UnknownTypes == config.UnknownTypesDeadLetter {
+		candidates = append(candidates, c.Kafka.DeadLetterTopic)
+	}
+	for _, cl := range c.App.Clients {
+		candidates = append(candidates, cl.Topic)
+	}
+
+	var topics []string
+	seen := make(map[string]bool)
+	for _, topic := range candidates {
+		if topic != "" && !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
@@ -0,0 +1,164 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type TestTopicChecker struct {
+	errs   map[string]error
+	err    error
+	topics []string
+	closed bool
+}
+
+func (t *TestTopicChecker) Check(_ context.Context, topics []string) (map[string]error, error) {
+	t.topics = topics
+	return t.errs, t.err
+}
+
+func (t *TestTopicChecker) Close() {
+	t.closed = true
+}
+
+func validConfig() config.AppConfig {
+	return config.AppConfig{
+		App: config.App{Http: config.Http{Port: "8080", Auth: config.Auth{User: "test", Password: "test"}}},
+		Kafka: config.Kafka{
+			BootstrapServers: "localhost:9092, kafka:9093",
+			OutputTopic:      "gics",
+			EpixTopic:        "epix",
+			GpasTopic:        "gpas",
+			SecurityProtocol: "plaintext",
+		},
+	}
+}
+
+func TestSettings(t *testing.T) {
+	r := Settings(validConfig())
+
+	assert.Equal(t, 0, r.Failed())
+}
+
+func TestSettings_Invalid(t *testing.T) {
+	c := validConfig()
+	c.App.Http.Port = "80800"
+	c.App.Http.Auth.Password = ""
+	c.App.Http.Admin.Enabled = true
+	c.Kafka.BootstrapServers = "localhost"
+	c.Kafka.SecurityProtocol = "tls"
+	c.Enrichment.Enabled = true
+	c.Gics.Url = "localhost:8080/gics"
+	c.Expiry.Enabled = true
+
+	r := Settings(c)
+
+	assert.Equal(t, 2, r.Failed())
+	assert.ErrorContains(t, r[0].Err, "app.http.auth.password is required")
+	assert.ErrorContains(t, r[0].Err, "app.http.admin.auth.user is required")
+	assert.ErrorContains(t, r[1].Err, "app.http.port: invalid port 80800")
+	assert.ErrorContains(t, r[1].Err, "kafka.bootstrap-servers")
+	assert.ErrorContains(t, r[1].Err, "kafka.security-protocol")
+	assert.ErrorContains(t, r[1].Err, "gics.url")
+	assert.ErrorContains(t, r[1].Err, "expiry.interval")
+}
+
+func TestSettings_SourceTopics(t *testing.T) {
+	c := validConfig()
+	c.Kafka.EpixTopic = ""
+	c.Kafka.GpasTopic = ""
+	// all sources by client id prefix
+	err := Required(c)
+	assert.ErrorContains(t, err, "kafka.epix-topic is required")
+	assert.ErrorContains(t, err, "kafka.gpas-topic is required")
+
+	// gICS only
+	c.App.Clients = []config.Client{{Id: "gICS_Web"}}
+	assert.NoError(t, Required(c))
+
+	c.App.Clients = []config.Client{
+		{Id: "gICS_Web"},
+		{Id: "E-PIX_Web", Topic: "epix"},
+		{Pattern: "Pseudonyms_.*", Source: "gPAS"},
+	}
+
+	err = Required(c)
+
+	assert.NotContains(t, err.Error(), "kafka.epix-topic")
+	assert.ErrorContains(t, err, "kafka.gpas-topic is required")
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	_ = os.WriteFile(ca, []byte("ca"), 0600)
+	c := validConfig()
+	c.Kafka.SecurityProtocol = "SSL"
+	c.Kafka.Ssl = config.Ssl{CaLocation: ca, KeyLocation: filepath.Join(dir, "key.pem")}
+	c.Kafka.Signing = config.Signing{Enabled: true, KeyId: "test", KeyFile: filepath.Join(dir, "signing.pem")}
+
+	r := Files(c)
+
+	if assert.Len(t, r, 2) {
+		assert.Equal(t, "ssl files", r[0].Name)
+		assert.ErrorContains(t, r[0].Err, "kafka.ssl.key-location")
+		assert.NotContains(t, r[0].Err.Error(), "kafka.ssl.ca-location")
+		assert.Equal(t, "signing key", r[1].Name)
+		assert.Error(t, r[1].Err)
+	}
+}
+
+func TestKafka(t *testing.T) {
+	c := validConfig()
+	c.App.Clients = []config.Client{{Id: "gICS_Web", Topic: "consents"}, {Id: "E-PIX_Web"}}
+	checker := &TestTopicChecker{errs: map[string]error{"gics": nil, "epix": errors.New("topic epix not available")}}
+
+	r := Kafka(context.Background(), c, func(config.Kafka) (TopicChecker, error) { return checker, nil })
+
+	// no gPAS client
+	assert.Equal(t, []string{"gics", "epix", "consents"}, checker.topics)
+	assert.True(t, checker.closed)
+	assert.Equal(t, []string{"kafka client", "kafka connection", "topic gics", "topic epix", "topic consents"}, names(r))
+	// topics missing in the result are not reported as passed
+	assert.Equal(t, 2, r.Failed())
+	assert.Error(t, r[4].Err)
+}
+
+func TestTopics_SourcePrefix(t *testing.T) {
+	assert.Equal(t, []string{"gics", "epix", "gpas"}, Topics(validConfig()))
+}
+
+func TestKafka_Errors(t *testing.T) {
+	c := validConfig()
+
+	r := Kafka(context.Background(), c, func(config.Kafka) (TopicChecker, error) { return nil, errors.New("ssl.key.location failed") })
+	assert.Equal(t, Report{{"kafka client", errors.New("ssl.key.location failed")}}, r)
+
+	r = Kafka(context.Background(), c, func(config.Kafka) (TopicChecker, error) {
+		return &TestTopicChecker{err: context.DeadlineExceeded}, nil
+	})
+	assert.Equal(t, []string{"kafka client", "kafka connection"}, names(r))
+	assert.ErrorIs(t, r[1].Err, context.DeadlineExceeded)
+}
+
+func TestReport_Print(t *testing.T) {
+	var b bytes.Buffer
+
+	Report{{"passed", nil}, {"failed", errors.Join(errors.New("first"), errors.New("second"))}}.Print(&b)
+
+	assert.Equal(t, "PASS  passed\nFAIL  failed\n      first\n      second\nCheck failed: 1 of 2 checks failed\n", b.String())
+}
+
+func names(r Report) []string {
+	var names []string
+	for _, res := range r {
+		names = append(names, res.Name)
+	}
+	return names
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"regexp"
+)
+
+var (
+	ErrUnknownClient = errors.New("client is not allowed")
+	ErrForbidden     = errors.New("client is not allowed for the authenticated user")
+)
+
+// Client is an allowed notification client
+type Client struct {
+	Source *notification.Source
+	// Topic overrides the source's default topic, if set
+	Topic  string
+	Key    string
+	Format string
+
+	id      string
+	pattern *regexp.Regexp
+	user    string
+}
+
+// Registry matches client ids to the configured clients
+type Registry struct {
+	clients []*Client
+	// default output format
+	format string
+}
+
+// NewRegistry validates the client configuration and compiles the patterns.
+// Clients without output format use the default format.
+func NewRegistry(cs []config.Client, format string) (*Registry, error) {
+	if format == "" {
+		format = config.FormatPayload
+	}
+	if !isFormat(format) {
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+
+	r := &Registry{format: format}
+	for i, c := range cs {
+		cl, err := newClient(c, format)
+		if err != nil {
+			return nil, fmt.Errorf("client %d: %w", i, err)
+		}
+		r.clients = append(r.clients, cl)
+	}
+	return r, nil
+}
+
+func newClient(c config.Client, format string) (*Client, error) {
+	cl := &Client{
+		Topic:  c.Topic,
+		Key:    c.Key,
+		Format: c.Format,
+		id:     c.Id,
+		user:   c.User,
+	}
+
+	if (c.Id == "") == (c.Pattern == "") {
+		return nil, errors.New("either id or pattern is required")
+	}
+	if c.Pattern != "" {
+		// the whole client id has to match
+		p, err := regexp.Compile(`^(?:` + c.Pattern + `)$`)
+		if err != nil {
+			return nil, err
+		}
+		cl.pattern = p
+	}
+
+	if c.Source != "" {
+		cl.Source = notification.SourceByName(c.Source)
+		if cl.Source == nil {
+			return nil, fmt.Errorf("unknown source: %s", c.Source)
+		}
+	} else if c.Id != "" {
+		cl.Source = notification.SourceOf(c.Id)
+	}
+	if cl.Source == nil {
+		return nil, errors.New("source is required")
+	}
+
+	switch c.Key {
+	case "":
+		cl.Key = config.KeyPayload
+	case config.KeyPayload, config.KeyNone:
+	default:
+		return nil, fmt.Errorf("unknown key strategy: %s", c.Key)
+	}
+
+	if c.Format == "" {
+		cl.Format = format
+	} else if !isFormat(c.Format) {
+		return nil, fmt.Errorf("unknown output format: %s", c.Format)
+	}
+
+	return cl, nil
+}
+
+func isFormat(format string) bool {
+	switch format {
+	case config.FormatPayload, config.FormatNotification, config.FormatRaw:
+		return true
+	}
+	return false
+}
+
+func (c *Client) matches(clientId string) bool {
+	if c.pattern != nil {
+		return c.pattern.MatchString(clientId)
+	}
+	return c.id == clientId
+}
+
+// Match returns the first client matching the client id, which is not bound
+// to another user. Without configured clients, the client id is matched by
+// source prefix.
+func (r *Registry) Match(clientId, user string) (*Client, error) {
+	if r == nil || len(r.clients) == 0 {
+		src := notification.SourceOf(clientId)
+		if src == nil {
+			return nil, ErrUnknownClient
+		}
+		format := config.FormatPayload
+		if r != nil {
+			format = r.format
+		}
+		return &Client{Source: src, Key: config.KeyPayload, Format: format}, nil
+	}
+
+	err := ErrUnknownClient
+	for _, c := range r.clients {
+		if !c.matches(clientId) {
+			continue
+		}
+		if c.user != "" && c.user != user {
+			err = ErrForbidden
+			continue
+		}
+		return c, nil
+	}
+	return nil, err
+}
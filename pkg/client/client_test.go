@@ -0,0 +1,106 @@
+package client
+
+import (
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewRegistry_Invalid(t *testing.T) {
+	cases := map[string]config.Client{
+		"idAndPattern":    {Id: "gICS_Web", Pattern: "^gICS_"},
+		"noIdOrPattern":   {Source: "gICS"},
+		"invalidPattern":  {Pattern: "gICS_(", Source: "gICS"},
+		"patternNoSource": {Pattern: "^gICS_"},
+		"unknownSource":   {Id: "gICS_Web", Source: "test"},
+		"idWithoutPrefix": {Id: "Web"},
+		"unknownKey":      {Id: "gICS_Web", Key: "test"},
+		"unknownFormat":   {Id: "gICS_Web", Format: "xml"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRegistry([]config.Client{c}, "")
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+type MatchTestCase struct {
+	name           string
+	clientId       string
+	user           string
+	expectedSource *notification.Source
+	expectedTopic  string
+	expectedErr    error
+}
+
+func TestRegistry_Match(t *testing.T) {
+	r, err := NewRegistry([]config.Client{
+		{Id: "gICS_Web", User: "gics"},
+		{Id: "Consent-Web", Source: "gics", Topic: "consents", Key: config.KeyNone},
+		{Pattern: "^Pseudonyms_[0-9]+$", Source: "gPAS", Format: config.FormatNotification},
+		{Pattern: "ePIX_.*", Source: "E-PIX"},
+	}, "")
+	assert.NoError(t, err)
+
+	cases := []MatchTestCase{
+		{name: "id", clientId: "gICS_Web", user: "gics", expectedSource: notification.GICS},
+		{name: "otherUser", clientId: "gICS_Web", user: "test", expectedErr: ErrForbidden},
+		{name: "customName", clientId: "Consent-Web", user: "test", expectedSource: notification.GICS, expectedTopic: "consents"},
+		{name: "pattern", clientId: "Pseudonyms_1", expectedSource: notification.GPAS},
+		{name: "patternNoMatch", clientId: "Pseudonyms_1x", expectedErr: ErrUnknownClient},
+		{name: "unanchoredPattern", clientId: "ePIX_Web", expectedSource: notification.EPIX},
+		{name: "unanchoredPatternNoMatch", clientId: "not_ePIX_Web", expectedErr: ErrUnknownClient},
+		{name: "unknown", clientId: "gICS_Other", expectedErr: ErrUnknownClient},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := r.Match(c.clientId, c.user)
+
+			if c.expectedErr != nil {
+				assert.ErrorIs(t, err, c.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedSource, actual.Source)
+			assert.Equal(t, c.expectedTopic, actual.Topic)
+		})
+	}
+}
+
+func TestRegistry_MatchDefaults(t *testing.T) {
+	r, _ := NewRegistry([]config.Client{{Id: "Consent-Web", Source: "gICS"}, {Id: "Raw", Source: "gICS", Key: config.KeyNone, Format: config.FormatNotification}}, config.FormatRaw)
+
+	actual, _ := r.Match("Consent-Web", "")
+	assert.Equal(t, config.KeyPayload, actual.Key)
+	assert.Equal(t, config.FormatRaw, actual.Format)
+
+	actual, _ = r.Match("Raw", "")
+	assert.Equal(t, config.KeyNone, actual.Key)
+	assert.Equal(t, config.FormatNotification, actual.Format)
+}
+
+func TestRegistry_MatchBySourcePrefix(t *testing.T) {
+	var r *Registry
+
+	actual, err := r.Match("E-PIX_Web", "")
+	assert.NoError(t, err)
+	assert.Equal(t, notification.EPIX, actual.Source)
+
+	_, err = r.Match("not_gICS_Web", "")
+	assert.ErrorIs(t, err, ErrUnknownClient)
+
+	r, _ = NewRegistry(nil, config.FormatRaw)
+	actual, _ = r.Match("gPAS_Web", "")
+	assert.Equal(t, config.FormatRaw, actual.Format)
+}
+
+func TestNewRegistry_InvalidFormat(t *testing.T) {
+	_, err := NewRegistry(nil, "xml")
+
+	assert.Error(t, err)
+}
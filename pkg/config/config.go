@@ -1,34 +1,202 @@
 package config
 
 import (
-	"github.com/phsym/console-slog"
+	"gics-to-kafka/pkg/correlation"
+	"gics-to-kafka/pkg/logging"
 	"github.com/spf13/viper"
+	"io"
 	"log/slog"
 	"os"
-	"strings"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// policies for notification types without payload model
+const (
+	UnknownTypesPass       = "pass"
+	UnknownTypesReject     = "reject"
+	UnknownTypesDeadLetter = "dead-letter"
+)
+
+// client key strategies
+const (
+	KeyPayload = "payload"
+	KeyNone    = "none"
+)
+
+// client output formats
+const (
+	FormatPayload      = "payload"
+	FormatNotification = "notification"
+	FormatRaw          = "raw"
+)
+
+// sources of the Kafka record timestamp
+const (
+	RecordTimestampCreatedAt   = "created-at"
+	RecordTimestampConsentDate = "consent-date"
+	RecordTimestampReceiveTime = "receive-time"
+)
+
+// output date formats
+const (
+	DateFormatOffset = "offset"
+	DateFormatUtc    = "utc"
 )
 
 type AppConfig struct {
-	App   App   `mapstructure:"app"`
-	Kafka Kafka `mapstructure:"kafka"`
+	App        App        `mapstructure:"app"`
+	Kafka      Kafka      `mapstructure:"kafka"`
+	Store      Store      `mapstructure:"store"`
+	Gics       Gics       `mapstructure:"gics"`
+	Enrichment Enrichment `mapstructure:"enrichment"`
+	Expiry     Expiry     `mapstructure:"expiry"`
+	Journal    Journal    `mapstructure:"journal"`
+}
+
+// Gics is the gICS SOAP service used to resolve consent templates
+type Gics struct {
+	Url     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Auth    Auth          `mapstructure:"auth"`
+}
+
+type Enrichment struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	CacheTtl time.Duration `mapstructure:"cache-ttl"`
+}
+
+// Expiry schedules expiration events for consents of templates with a validity
+type Expiry struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Path     string        `mapstructure:"path"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// Journal is the audit journal of received notifications
+type Journal struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+	MaxSize int    `mapstructure:"max-size"`
+}
+
+type Store struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"`
+	Rebuild bool   `mapstructure:"rebuild"`
 }
 
 type Http struct {
-	Auth Auth   `mapstructure:"auth"`
-	Port string `mapstructure:"port"`
+	Auth  Auth   `mapstructure:"auth"`
+	Port  string `mapstructure:"port"`
+	Batch Batch  `mapstructure:"batch"`
+	Admin Admin  `mapstructure:"admin"`
+	// Retry-After of requests rejected while paused
+	RetryAfter time.Duration `mapstructure:"retry-after"`
+}
+
+// Admin is the operations API with its own credentials
+type Admin struct {
+	Enabled bool `mapstructure:"enabled"`
+	Auth    Auth `mapstructure:"auth"`
+	// number of recent failures to keep
+	Failures int `mapstructure:"failures"`
+}
+
+type Batch struct {
+	Concurrency int `mapstructure:"concurrency"`
+	// max. notifications per request
+	MaxSize int `mapstructure:"max-size"`
+	// max. request body size in MB
+	MaxBodySize int `mapstructure:"max-body-size"`
 }
 
 type App struct {
-	Name     string `mapstructure:"name"`
-	LogLevel string `mapstructure:"log-level"`
-	Http     Http   `mapstructure:"http"`
+	Name      string   `mapstructure:"name"`
+	LogLevel  string   `mapstructure:"log-level"`
+	LogFormat string   `mapstructure:"log-format"`
+	LogFile   LogFile  `mapstructure:"log-file"`
+	LogRedact []string `mapstructure:"log-redact"`
+	// profile files merged over app.yml, comma separated
+	Profile      string   `mapstructure:"profile"`
+	UnknownTypes string   `mapstructure:"unknown-types"`
+	OutputFormat string   `mapstructure:"output-format"`
+	Clients      []Client `mapstructure:"clients"`
+	Time         Time     `mapstructure:"time"`
+	Http         Http     `mapstructure:"http"`
+	Tracing      Tracing  `mapstructure:"tracing"`
+}
+
+// Client allows notifications from client ids matching Id or Pattern and
+// overrides how they are sent to Kafka
+type Client struct {
+	Id      string `mapstructure:"id"`
+	Pattern string `mapstructure:"pattern"`
+	Source  string `mapstructure:"source"`
+	User    string `mapstructure:"user"`
+	Topic   string `mapstructure:"topic"`
+	Key     string `mapstructure:"key"`
+	Format  string `mapstructure:"format"`
+}
+
+// Time configures how notification timestamps are parsed
+type Time struct {
+	Zone            string   `mapstructure:"zone"`
+	Layouts         []string `mapstructure:"layouts"`
+	RecordTimestamp string   `mapstructure:"record-timestamp"`
+}
+
+type LogFile struct {
+	Path       string `mapstructure:"path"`
+	MaxSize    int    `mapstructure:"max-size"`
+	MaxBackups int    `mapstructure:"max-backups"`
+	MaxAge     int    `mapstructure:"max-age"`
+	Compress   bool   `mapstructure:"compress"`
+}
+
+type Tracing struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample-ratio"`
 }
 
 type Kafka struct {
-	BootstrapServers string `mapstructure:"bootstrap-servers"`
-	OutputTopic      string `mapstructure:"output-topic"`
-	SecurityProtocol string `mapstructure:"security-protocol"`
-	Ssl              Ssl    `mapstructure:"ssl"`
+	BootstrapServers   string              `mapstructure:"bootstrap-servers"`
+	OutputTopic        string              `mapstructure:"output-topic"`
+	EpixTopic          string              `mapstructure:"epix-topic"`
+	GpasTopic          string              `mapstructure:"gpas-topic"`
+	SnapshotTopic      string              `mapstructure:"snapshot-topic"`
+	DeadLetterTopic    string              `mapstructure:"dead-letter-topic"`
+	NormalizeDates     []DateNormalization `mapstructure:"normalize-dates"`
+	Signing            Signing             `mapstructure:"signing"`
+	Encryption         Encryption          `mapstructure:"encryption"`
+	SecurityProtocol   string              `mapstructure:"security-protocol"`
+	Ssl                Ssl                 `mapstructure:"ssl"`
+	StatisticsInterval time.Duration       `mapstructure:"statistics-interval"`
+}
+
+// DateNormalization converts the date fields of a topic's records to RFC 3339
+// with the source's offset or in UTC
+type DateNormalization struct {
+	Topic  string `mapstructure:"topic"`
+	Format string `mapstructure:"format"`
+}
+
+// Signing signs all produced records with the Ed25519 key identified by KeyId
+type Signing struct {
+	Enabled bool   `mapstructure:"enabled"`
+	KeyId   string `mapstructure:"key-id"`
+	KeyFile string `mapstructure:"key-file"`
+}
+
+// Encryption encrypts identifying fields with per-record data keys wrapped by
+// the master key KeyId, read from KeyDir
+type Encryption struct {
+	Enabled bool   `mapstructure:"enabled"`
+	KeyId   string `mapstructure:"key-id"`
+	KeyDir  string `mapstructure:"key-dir"`
 }
 
 type Ssl struct {
@@ -39,28 +207,97 @@ type Ssl struct {
 }
 
 type Auth struct {
+	User     string    `mapstructure:"user"`
+	Password string    `mapstructure:"password"`
+	Accounts []Account `mapstructure:"accounts"`
+}
+
+// Account is an additional Basic Auth credential
+type Account struct {
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 }
 
+// defaults of the shipped app.yml, so properties not set in a config file or
+// the environment keep their documented value. Credentials have no defaults.
+var defaults = map[string]any{
+	"app.name":                       "gics-to-kafka",
+	"app.log-level":                  "info",
+	"app.log-format":                 "console",
+	"app.log-file.max-size":          100,
+	"app.log-file.max-backups":       5,
+	"app.log-file.max-age":           30,
+	"app.log-redact":                 []string{"password", "key-password", "authorization", "body", "id", "signerId", "signerIds", "inspector"},
+	"app.unknown-types":              UnknownTypesPass,
+	"app.output-format":              FormatPayload,
+	"app.time.zone":                  "Europe/Berlin",
+	"app.time.layouts":               []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00"},
+	"app.time.record-timestamp":      RecordTimestampCreatedAt,
+	"app.http.port":                  "8080",
+	"app.http.batch.concurrency":     50,
+	"app.http.batch.max-size":        1000,
+	"app.http.batch.max-body-size":   10,
+	"app.http.admin.auth.user":       "admin",
+	"app.http.admin.failures":        100,
+	"app.http.retry-after":           time.Minute,
+	"app.tracing.endpoint":           "localhost:4318",
+	"app.tracing.insecure":           true,
+	"app.tracing.sample-ratio":       1.0,
+	"kafka.bootstrap-servers":        "localhost:9092",
+	"kafka.security-protocol":        "ssl",
+	"kafka.ssl.ca-location":          "/app/cert/kafka-ca.pem",
+	"kafka.ssl.certificate-location": "/app/cert/app-cert.pem",
+	"kafka.ssl.key-location":         "/app/cert/app-key.pem",
+	"kafka.output-topic":             "gics-notification",
+	"kafka.epix-topic":               "epix-notification",
+	"kafka.gpas-topic":               "gpas-notification",
+	"kafka.dead-letter-topic":        "gics-notification-dlq",
+	"kafka.statistics-interval":      time.Minute,
+	"kafka.signing.key-file":         "/app/cert/signing-key.pem",
+	"kafka.encryption.key-dir":       "/app/cert/master-keys",
+	"store.path":                     "/app/data/consents.db",
+	"store.rebuild":                  true,
+	"gics.url":                       "http://localhost:8080/gics/gicsService",
+	"gics.timeout":                   10 * time.Second,
+	"enrichment.cache-ttl":           time.Hour,
+	"expiry.path":                    "/app/data/expiry.db",
+	"expiry.interval":                time.Minute,
+	"journal.path":                   "/app/data/journal.jsonl",
+	"journal.max-size":               100,
+}
+
+// parseConfig reads the config file of the path, a file or a directory with
+// app.yml. Without app.yml, the configuration is read from the environment only.
 func parseConfig(path string) (config *AppConfig, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName("app")
 	viper.SetConfigType("yml")
 
 	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(`.`, `_`, `-`, `_`))
+	viper.SetEnvKeyReplacer(envKeyReplacer)
+	bindEnv()
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
 
-	err = viper.ReadInConfig()
+	baseFile, configDir, err = locate(path)
 	if err != nil {
 		return nil, err
 	}
-
-	err = viper.Unmarshal(&config)
-	return config, err
+	if baseFile == "" {
+		slog.Info("No config file found, using environment variables only", "path", path)
+	}
+	return readConfig()
 }
 
+// LoadConfig loads the configuration from the path, the GICS_TO_KAFKA_CONFIG
+// environment variable or the working directory
 func LoadConfig(path string) *AppConfig {
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+	if path == "" {
+		path = "."
+	}
+
 	c, err := parseConfig(path)
 	if err != nil {
 		slog.Error("Unable to load config file", "error", err)
@@ -69,14 +306,75 @@ func LoadConfig(path string) *AppConfig {
 	return c
 }
 
+// secretKeys are masked in the effective configuration
+var secretKeys = []string{"password", "key-password"}
+
+// Masked returns the configuration by property name with all secrets masked
+func (c AppConfig) Masked() map[string]any {
+	return masked(reflect.ValueOf(c)).(map[string]any)
+}
+
+func masked(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			key := v.Type().Field(i).Tag.Get("mapstructure")
+			f := v.Field(i)
+			if slices.Contains(secretKeys, key) && f.String() != "" {
+				m[key] = "***"
+				continue
+			}
+			m[key] = masked(f)
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		s := make([]any, v.Len())
+		for i := range v.Len() {
+			s[i] = masked(v.Index(i))
+		}
+		return s
+	default:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return d.String()
+		}
+		return v.Interface()
+	}
+}
+
+// logLevel of the configured logger, can be changed at runtime
+var logLevel = new(slog.LevelVar)
+
+// SetLogLevel changes the log level of the configured logger
+func SetLogLevel(level string) error {
+	return logLevel.UnmarshalText([]byte(level))
+}
+
 func ConfigureLogger(c App) {
-	lvl := new(slog.LevelVar)
-	lvl.Set(slog.LevelInfo)
-	logger := slog.New(console.NewHandler(os.Stderr, &console.HandlerOptions{Level: lvl}))
+	logLevel.Set(slog.LevelInfo)
+
+	var out io.Writer = os.Stderr
+	if c.LogFile.Path != "" {
+		out = io.MultiWriter(os.Stderr, logging.NewFileWriter(c.LogFile.Path,
+			c.LogFile.MaxSize, c.LogFile.MaxBackups, c.LogFile.MaxAge, c.LogFile.Compress))
+	}
+
+	h, formatErr := logging.NewHandler(out, c.LogFormat, logLevel, c.LogFile.Path == "")
+	if formatErr != nil {
+		h, _ = logging.NewHandler(out, logging.FormatConsole, logLevel, c.LogFile.Path == "")
+	}
+	logger := slog.New(correlation.NewHandler(logging.NewRedactHandler(h, c.LogRedact)))
 	slog.SetDefault(logger)
 
+	if formatErr != nil {
+		slog.Error("Unable to set Log format from application properties", "format", c.LogFormat, "error", formatErr)
+	}
+
 	// set configured log level
-	err := lvl.UnmarshalText([]byte(c.LogLevel))
+	err := logLevel.UnmarshalText([]byte(c.LogLevel))
 	if err != nil {
 		slog.Error("Unable to set Log level from application properties", "level", c.LogLevel, "error", err)
 	}
@@ -2,13 +2,17 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestConfigureLoggerSetsLogLevel(t *testing.T) {
@@ -35,30 +39,59 @@ func TestParseConfigWithEnv(t *testing.T) {
 func TestParseConfigFileNotFound(t *testing.T) {
 	setProjectDir()
 
-	// config file not found
+	// config path not found
 	_, err := parseConfig("./bla")
 
-	if assert.Error(t, err) {
-		_, isConfigError := err.(viper.ConfigFileNotFoundError)
-
-		assert.True(t, isConfigError)
-	}
+	assert.ErrorIs(t, err, fs.ErrNotExist)
 }
 
 func TestLoadDefaultConfig(t *testing.T) {
 	setProjectDir()
 	defaultConfig := AppConfig{
 		App: App{
-			Name:     "gics-to-kafka",
-			LogLevel: "info",
+			Name:      "gics-to-kafka",
+			LogLevel:  "info",
+			LogFormat: "console",
+			LogFile: LogFile{
+				MaxSize:    100,
+				MaxBackups: 5,
+				MaxAge:     30,
+			},
+			LogRedact:    []string{"password", "key-password", "authorization", "body", "id", "signerId", "signerIds", "inspector"},
+			UnknownTypes: "pass",
+			OutputFormat: "payload",
+			Time: Time{
+				Zone:            "Europe/Berlin",
+				Layouts:         []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00"},
+				RecordTimestamp: "created-at",
+			},
 			Http: Http{Port: "8080", Auth: Auth{
 				User:     "test",
 				Password: "test",
-			}},
+			}, Batch: Batch{Concurrency: 50, MaxSize: 1000, MaxBodySize: 10}, Admin: Admin{
+				Auth:     Auth{User: "admin"},
+				Failures: 100,
+			}, RetryAfter: time.Minute},
+			Tracing: Tracing{
+				Enabled:     false,
+				Endpoint:    "localhost:4318",
+				Insecure:    true,
+				SampleRatio: 1.0,
+			},
 		},
 		Kafka: Kafka{
-			BootstrapServers: "localhost:9092",
-			OutputTopic:      "gics-notification",
+			BootstrapServers:   "localhost:9092",
+			OutputTopic:        "gics-notification",
+			EpixTopic:          "epix-notification",
+			GpasTopic:          "gpas-notification",
+			DeadLetterTopic:    "gics-notification-dlq",
+			StatisticsInterval: time.Minute,
+			Signing: Signing{
+				KeyFile: "/app/cert/signing-key.pem",
+			},
+			Encryption: Encryption{
+				KeyDir: "/app/cert/master-keys",
+			},
 			SecurityProtocol: "ssl",
 			Ssl: Ssl{
 				CaLocation:          "/app/cert/kafka-ca.pem",
@@ -66,6 +99,26 @@ func TestLoadDefaultConfig(t *testing.T) {
 				KeyLocation:         "/app/cert/app-key.pem",
 			},
 		},
+		Store: Store{
+			Enabled: false,
+			Path:    "/app/data/consents.db",
+			Rebuild: true,
+		},
+		Gics: Gics{
+			Url:     "http://localhost:8080/gics/gicsService",
+			Timeout: 10 * time.Second,
+		},
+		Enrichment: Enrichment{
+			CacheTtl: time.Hour,
+		},
+		Expiry: Expiry{
+			Path:     "/app/data/expiry.db",
+			Interval: time.Minute,
+		},
+		Journal: Journal{
+			Path:    "/app/data/journal.jsonl",
+			MaxSize: 100,
+		},
 	}
 	actual := *LoadConfig(".")
 
@@ -75,7 +128,7 @@ func TestLoadDefaultConfig(t *testing.T) {
 func TestLoadConfig_Error(t *testing.T) {
 	setProjectDir()
 
-	actual := LoadConfig("/")
+	actual := LoadConfig("/does/not/exist")
 
 	assert.Nil(t, actual)
 }
@@ -91,6 +144,22 @@ func TestConfigureLogger(t *testing.T) {
 	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelInfo))
 }
 
+func TestConfigureLogger_Format(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	ConfigureLogger(App{LogLevel: "info", LogFormat: "json", LogFile: LogFile{Path: path}, LogRedact: []string{"password"}})
+	slog.Info("test", "password", "secret")
+
+	actual, _ := os.ReadFile(path)
+	assert.Contains(t, string(actual), `"msg":"test","password":"***"`)
+}
+
+func TestConfigureLogger_InvalidFormat(t *testing.T) {
+	ConfigureLogger(App{LogLevel: "info", LogFormat: "xml"})
+
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelInfo))
+}
+
 func setProjectDir() {
 	_, filename, _, _ := runtime.Caller(0)
 	dir := path.Join(path.Dir(filename), "../..")
@@ -98,3 +167,51 @@ func setProjectDir() {
 
 	viper.Reset()
 }
+
+func TestMasked(t *testing.T) {
+	c := AppConfig{
+		App: App{Http: Http{Auth: Auth{User: "test", Password: "secret", Accounts: []Account{{User: "epix", Password: "secret"}}}}},
+		Kafka: Kafka{
+			Ssl:                Ssl{KeyPassword: "secret"},
+			StatisticsInterval: time.Minute,
+		},
+	}
+
+	actual := c.Masked()
+
+	app := actual["app"].(map[string]any)
+	auth := app["http"].(map[string]any)["auth"].(map[string]any)
+	assert.Equal(t, "test", auth["user"])
+	assert.Equal(t, "***", auth["password"])
+	assert.Equal(t, "***", auth["accounts"].([]any)[0].(map[string]any)["password"])
+	kafka := actual["kafka"].(map[string]any)
+	assert.Equal(t, "***", kafka["ssl"].(map[string]any)["key-password"])
+	assert.Equal(t, "1m0s", kafka["statistics-interval"])
+	// empty secrets are not masked
+	assert.Equal(t, "", actual["gics"].(map[string]any)["auth"].(map[string]any)["password"])
+	assert.NotContains(t, fmt.Sprint(actual), "secret")
+}
+
+func TestSetLogLevel(t *testing.T) {
+	ConfigureLogger(App{LogLevel: "info"})
+
+	assert.NoError(t, SetLogLevel("debug"))
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+
+	assert.Error(t, SetLogLevel("verbose"))
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+
+	assert.NoError(t, SetLogLevel("info"))
+	assert.False(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+}
+
+func TestReload(t *testing.T) {
+	setProjectDir()
+	LoadConfig(".")
+
+	t.Setenv("KAFKA_OUTPUT_TOPIC", "reloaded")
+	actual, err := Reload()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "reloaded", actual.Kafka.OutputTopic)
+}
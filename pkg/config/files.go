@@ -0,0 +1,188 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// EnvConfigPath selects the config file or directory if no path is passed
+const EnvConfigPath = "GICS_TO_KAFKA_CONFIG"
+
+// secretFileSuffix of environment variables naming a file with the property value
+const secretFileSuffix = "_FILE"
+
+var envKeyReplacer = strings.NewReplacer(`.`, `_`, `-`, `_`)
+
+// location of the configuration files, baseFile is empty in env-only mode
+var (
+	baseFile  string
+	configDir string
+)
+
+// locate returns the config file of the path, which is either a file or a
+// directory with an optional app.yml
+func locate(path string) (file, dir string, err error) {
+	if path, err = filepath.Abs(path); err != nil {
+		return "", "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", err
+	}
+	if !info.IsDir() {
+		return path, filepath.Dir(path), nil
+	}
+
+	file = filepath.Join(path, "app.yml")
+	if _, err = os.Stat(file); err != nil {
+		return "", path, nil
+	}
+	return file, path, nil
+}
+
+// profileFiles returns the files of the profiles set in app.profile, e.g.
+// app-prod.yml for profile prod
+func profileFiles() []string {
+	var files []string
+	for _, p := range strings.Split(viper.GetString("app.profile"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			files = append(files, filepath.Join(configDir, "app-"+p+".yml"))
+		}
+	}
+	return files
+}
+
+// readConfig reads the config file, merges the profile files and sets the
+// properties provided as secret files
+func readConfig() (config *AppConfig, err error) {
+	if baseFile != "" {
+		if err = readFile(baseFile, viper.ReadConfig); err != nil {
+			return nil, err
+		}
+	} else if err = viper.ReadConfig(bytes.NewReader(nil)); err != nil {
+		return nil, err
+	}
+	for _, f := range profileFiles() {
+		if err = readFile(f, viper.MergeConfig); err != nil {
+			return nil, err
+		}
+	}
+	if err = readSecretFiles(); err != nil {
+		return nil, err
+	}
+
+	err = viper.Unmarshal(&config)
+	return config, err
+}
+
+func readFile(path string, read func(in io.Reader) error) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = read(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readSecretFiles sets each property with a <ENV_NAME>_FILE environment variable
+// to the content of that file, e.g. Docker or Kubernetes secrets
+func readSecretFiles() error {
+	for _, key := range propertyKeys(reflect.TypeOf(AppConfig{}), "") {
+		path, ok := os.LookupEnv(strings.ToUpper(envKeyReplacer.Replace(key)) + secretFileSuffix)
+		if !ok {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("secret file of %s: %w", key, err)
+		}
+		viper.Set(key, strings.TrimRight(string(b), "\r\n"))
+	}
+	return nil
+}
+
+// bindEnv makes all properties available as environment variables, even if
+// they are not set in a config file
+func bindEnv() {
+	for _, key := range propertyKeys(reflect.TypeOf(AppConfig{}), "") {
+		_ = viper.BindEnv(key)
+	}
+}
+
+// propertyKeys returns the names of all properties of the config struct
+func propertyKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := prefix + f.Tag.Get("mapstructure")
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, propertyKeys(f.Type, name+".")...)
+		} else {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// Reload reads the configuration files again
+func Reload() (*AppConfig, error) {
+	return readConfig()
+}
+
+// Watch calls onChange whenever one of the configuration files changes
+func Watch(onChange func()) {
+	files := profileFiles()
+	if baseFile != "" {
+		files = append(files, baseFile)
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Error("Unable to watch configuration files", "error", err)
+		return
+	}
+	for _, f := range files {
+		// watch the directory to notice files replaced by editors or Kubernetes
+		if err = w.Add(filepath.Dir(f)); err != nil {
+			slog.Error("Unable to watch configuration files", "error", err)
+			_ = w.Close()
+			return
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				// Kubernetes updates mounted config maps by swapping the ..data link
+				if slices.Contains(files, filepath.Clean(e.Name)) || filepath.Base(e.Name) == "..data" {
+					if e.Has(fsnotify.Write) || e.Has(fsnotify.Create) {
+						onChange()
+					}
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("Unable to watch configuration files", "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	setProjectDir()
+	f := filepath.Join(t.TempDir(), "custom.yml")
+	writeFile(t, f, "kafka:\n  output-topic: custom\n")
+
+	actual := LoadConfig(f)
+
+	assert.Equal(t, "custom", actual.Kafka.OutputTopic)
+}
+
+func TestLoadConfig_EnvConfigPath(t *testing.T) {
+	setProjectDir()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app.yml"), "kafka:\n  output-topic: from-env-path\n")
+	t.Setenv(EnvConfigPath, dir)
+
+	actual := LoadConfig("")
+
+	assert.Equal(t, "from-env-path", actual.Kafka.OutputTopic)
+}
+
+func TestLoadConfig_EnvOnly(t *testing.T) {
+	setProjectDir()
+	t.Setenv("APP_HTTP_PORT", "9090")
+	t.Setenv("KAFKA_SSL_KEY_PASSWORD", "secret")
+
+	actual := LoadConfig(t.TempDir())
+
+	assert.Equal(t, "9090", actual.App.Http.Port)
+	assert.Equal(t, "secret", actual.Kafka.Ssl.KeyPassword)
+	// defaults of app.yml, except credentials
+	assert.Equal(t, "gics-notification", actual.Kafka.OutputTopic)
+	assert.Equal(t, "", actual.App.Http.Auth.User)
+}
+
+func TestLoadConfig_DefaultsMatchAppYml(t *testing.T) {
+	setProjectDir()
+	expected := *LoadConfig(".")
+	expected.App.Http.Auth = Auth{}
+
+	actual := LoadConfig(t.TempDir())
+
+	assert.Equal(t, expected, *actual)
+}
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	setProjectDir()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "app.yml"), "app:\n  profile: prod\n  log-level: info\nkafka:\n  output-topic: base\n  epix-topic: epix\n")
+	writeFile(t, filepath.Join(dir, "app-prod.yml"), "kafka:\n  output-topic: prod\n")
+	writeFile(t, filepath.Join(dir, "app-local.yml"), "app:\n  log-level: debug\n")
+
+	actual := LoadConfig(dir)
+
+	assert.Equal(t, "prod", actual.Kafka.OutputTopic)
+	assert.Equal(t, "epix", actual.Kafka.EpixTopic)
+	assert.Equal(t, "info", actual.App.LogLevel)
+
+	// profiles selected by environment
+	t.Setenv("APP_PROFILE", "prod, local")
+	actual = LoadConfig(dir)
+
+	assert.Equal(t, "prod", actual.Kafka.OutputTopic)
+	assert.Equal(t, "debug", actual.App.LogLevel)
+
+	t.Setenv("APP_PROFILE", "missing")
+	assert.Nil(t, LoadConfig(dir))
+}
+
+func TestLoadConfig_SecretFiles(t *testing.T) {
+	setProjectDir()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "password"), "secret\n")
+	t.Setenv("APP_HTTP_AUTH_PASSWORD_FILE", filepath.Join(dir, "password"))
+	t.Setenv("KAFKA_SSL_KEY_PASSWORD_FILE", filepath.Join(dir, "password"))
+
+	actual := LoadConfig(".")
+
+	assert.Equal(t, "secret", actual.App.Http.Auth.Password)
+	assert.Equal(t, "secret", actual.Kafka.Ssl.KeyPassword)
+
+	t.Setenv("KAFKA_SSL_KEY_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	assert.Nil(t, LoadConfig("."))
+}
+
+func TestWatch(t *testing.T) {
+	setProjectDir()
+	dir := t.TempDir()
+	f := filepath.Join(dir, "app.yml")
+	writeFile(t, f, "kafka:\n  output-topic: before\n")
+	LoadConfig(dir)
+
+	changed := make(chan struct{}, 10)
+	Watch(func() { changed <- struct{}{} })
+	writeFile(t, f, "kafka:\n  output-topic: after\n")
+
+	select {
+	case <-changed:
+		actual, err := Reload()
+		assert.NoError(t, err)
+		assert.Equal(t, "after", actual.Kafka.OutputTopic)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "config change not noticed")
+	}
+}
+
+func TestPropertyKeys(t *testing.T) {
+	actual := propertyKeys(reflect.TypeOf(Ssl{}), "kafka.ssl.")
+
+	assert.Equal(t, []string{"kafka.ssl.ca-location", "kafka.ssl.certificate-location", "kafka.ssl.key-location", "kafka.ssl.key-password"}, actual)
+}
@@ -0,0 +1,35 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	ctx := NewContext(context.Background(), "test")
+
+	assert.Equal(t, "test", FromContext(ctx))
+	assert.Empty(t, FromContext(context.Background()))
+}
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil)))
+
+	logger.InfoContext(NewContext(context.Background(), "4711"), "test")
+
+	assert.Contains(t, buf.String(), "requestId=4711")
+}
+
+func TestHandler_WithoutId(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil))).With("foo", "bar").WithGroup("g")
+
+	logger.InfoContext(context.Background(), "test", "a", 1)
+
+	assert.NotContains(t, buf.String(), "requestId")
+	assert.Contains(t, buf.String(), "foo=bar g.a=1")
+}
@@ -0,0 +1,158 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Key is the output section with the envelope of encrypted fields
+const Key = "encryption"
+
+const algorithm = "A256GCM"
+
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+// Header describes how the fields of a record were encrypted
+type Header struct {
+	Alg string `json:"alg"`
+	// Kid is the id of the master key the data key was wrapped with
+	Kid string `json:"kid"`
+	// Key is the wrapped data key
+	Key []byte `json:"key"`
+	// Fields are the JSON paths of the encrypted values
+	Fields []string `json:"fields"`
+}
+
+// Envelope encrypts the fields of a single record with its own data key
+type Envelope struct {
+	aead   cipher.AEAD
+	header Header
+}
+
+// New creates an envelope with a random data key wrapped by the KMS
+func New(ctx context.Context, kms KMS) (*Envelope, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newAead(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	keyId, wrapped, err := kms.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{aead: aead, header: Header{Alg: algorithm, Kid: keyId, Key: wrapped}}, nil
+}
+
+// Encrypt returns the encrypted value of the field at the JSON path, bound to
+// the path so values cannot be swapped between fields
+func (e *Envelope) Encrypt(path, value string) string {
+	e.header.Fields = append(e.header.Fields, path)
+	return base64.StdEncoding.EncodeToString(seal(e.aead, []byte(value), []byte(path)))
+}
+
+// Header returns the envelope's header with the encrypted fields sorted
+func (e *Envelope) Header() Header {
+	h := e.header
+	h.Fields = slices.Sorted(slices.Values(h.Fields))
+	return h
+}
+
+// Decrypt returns the JSON record with all encrypted fields decrypted and the
+// envelope removed. Records without envelope are returned unchanged.
+func Decrypt(ctx context.Context, kms KMS, record []byte) ([]byte, error) {
+	if record == nil {
+		return nil, nil
+	}
+
+	var envelope struct {
+		Header *Header `json:"encryption"`
+	}
+	if err := json.Unmarshal(record, &envelope); err != nil {
+		return nil, err
+	}
+	header := envelope.Header
+	if header == nil {
+		return record, nil
+	}
+	if header.Alg != algorithm {
+		return nil, fmt.Errorf("unsupported algorithm: %s", header.Alg)
+	}
+	dataKey, err := kms.UnwrapKey(ctx, header.Kid, header.Key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep numbers as they are
+	var doc map[string]any
+	d := json.NewDecoder(bytes.NewReader(record))
+	d.UseNumber()
+	if err = d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	delete(doc, Key)
+
+	for _, path := range header.Fields {
+		if err = decryptField(doc, path, aead); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func decryptField(doc any, path string, aead cipher.AEAD) error {
+	parent, last := doc, ""
+	segments := strings.Split(path, ".")
+	for i, s := range segments {
+		if i == len(segments)-1 {
+			last = s
+			break
+		}
+		switch p := parent.(type) {
+		case map[string]any:
+			parent = p[s]
+		case []any:
+			idx, err := strconv.Atoi(s)
+			if err != nil || idx < 0 || idx >= len(p) {
+				return ErrDecryptionFailed
+			}
+			parent = p[idx]
+		default:
+			return ErrDecryptionFailed
+		}
+	}
+
+	obj, ok := parent.(map[string]any)
+	if !ok {
+		return ErrDecryptionFailed
+	}
+	encrypted, ok := obj[last].(string)
+	if !ok {
+		return ErrDecryptionFailed
+	}
+	c, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return ErrDecryptionFailed
+	}
+	plaintext, err := open(aead, c, []byte(path))
+	if err != nil {
+		return err
+	}
+	obj[last] = string(plaintext)
+	return nil
+}
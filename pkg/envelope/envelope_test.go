@@ -0,0 +1,101 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func testKMS(t *testing.T) *LocalKMS {
+	kms, err := NewLocalKMS("2024-01", map[string][]byte{"2024-01": newMasterKey(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kms
+}
+
+// encrypt encrypts the signer id of the test record
+func encrypt(t *testing.T, kms KMS) []byte {
+	env, err := New(context.Background(), kms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := map[string]any{
+		"consentKey": map[string]any{
+			"signerIds": []any{map[string]any{"idType": "Patienten-ID", "id": env.Encrypt("consentKey.signerIds.0.id", "4711"), "orderNumber": 1}},
+		},
+		Key: env.Header(),
+	}
+	b, _ := json.Marshal(record)
+	return b
+}
+
+func TestDecrypt(t *testing.T) {
+	kms := testKMS(t)
+	record := encrypt(t, kms)
+	assert.NotContains(t, string(record), "4711")
+
+	actual, err := Decrypt(context.Background(), kms, record)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"consentKey":{"signerIds":[{"idType":"Patienten-ID","id":"4711","orderNumber":1}]}}`, string(actual))
+}
+
+func TestDecrypt_Unencrypted(t *testing.T) {
+	record := []byte(`{"consentKey":{}}`)
+
+	actual, err := Decrypt(context.Background(), testKMS(t), record)
+	assert.NoError(t, err)
+	assert.Equal(t, record, actual)
+
+	// tombstone
+	actual, err = Decrypt(context.Background(), testKMS(t), nil)
+	assert.NoError(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestDecrypt_WrongKey(t *testing.T) {
+	record := encrypt(t, testKMS(t))
+
+	_, err := Decrypt(context.Background(), testKMS(t), record)
+
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestDecrypt_MovedField(t *testing.T) {
+	kms := testKMS(t)
+	env, _ := New(context.Background(), kms)
+	b, _ := json.Marshal(map[string]any{
+		"a": env.Encrypt("b", "4711"),
+		"b": env.Encrypt("a", "0815"),
+		Key: env.Header(),
+	})
+
+	_, err := Decrypt(context.Background(), kms, b)
+
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestDecrypt_MissingField(t *testing.T) {
+	kms := testKMS(t)
+	record := strings.Replace(string(encrypt(t, kms)), `"signerIds"`, `"other"`, 1)
+
+	_, err := Decrypt(context.Background(), kms, []byte(record))
+
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestEnvelope_Header(t *testing.T) {
+	env, _ := New(context.Background(), testKMS(t))
+	_ = env.Encrypt("b", "x")
+	_ = env.Encrypt("a", "y")
+
+	actual := env.Header()
+
+	assert.Equal(t, "A256GCM", actual.Alg)
+	assert.Equal(t, "2024-01", actual.Kid)
+	assert.Equal(t, []string{"a", "b"}, actual.Fields)
+	assert.NotEmpty(t, actual.Key)
+}
@@ -0,0 +1,119 @@
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown master key")
+	ErrInvalidKey = errors.New("invalid master key")
+)
+
+// KMS wraps per-message data keys with master keys
+type KMS interface {
+	// WrapKey encrypts the data key with the current master key and returns its id
+	WrapKey(ctx context.Context, dataKey []byte) (keyId string, wrapped []byte, err error)
+	// UnwrapKey decrypts the data key with the identified master key
+	UnwrapKey(ctx context.Context, keyId string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKMS wraps data keys with AES-256-GCM master keys held in memory
+type LocalKMS struct {
+	keyId string
+	keys  map[string]cipher.AEAD
+}
+
+// NewLocalKMS creates a KMS with the master keys by id. Data keys are wrapped
+// with the key identified by keyId, which may be empty to only unwrap keys.
+func NewLocalKMS(keyId string, keys map[string][]byte) (*LocalKMS, error) {
+	k := &LocalKMS{keyId: keyId, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		aead, err := newAead(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKey, id, err)
+		}
+		k.keys[id] = aead
+	}
+	if _, found := k.keys[keyId]; keyId != "" && !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyId)
+	}
+	return k, nil
+}
+
+// LoadLocalKMS reads all master keys of the directory. Each file named
+// <key id>.key contains a base64 encoded 256 bit key.
+func LoadLocalKMS(keyId, dir string) (*LocalKMS, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]byte, len(files))
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKey, f, err)
+		}
+		keys[strings.TrimSuffix(filepath.Base(f), ".key")] = key
+	}
+	return NewLocalKMS(keyId, keys)
+}
+
+func (k *LocalKMS) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	aead, found := k.keys[k.keyId]
+	if !found {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownKey, k.keyId)
+	}
+	return k.keyId, seal(aead, dataKey, []byte(k.keyId)), nil
+}
+
+func (k *LocalKMS) UnwrapKey(_ context.Context, keyId string, wrapped []byte) ([]byte, error) {
+	aead, found := k.keys[keyId]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyId)
+	}
+	return open(aead, wrapped, []byte(keyId))
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("expected 256 bit key, got %d bit", len(key)*8)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext with a random nonce, which is prepended
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) []byte {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	_, _ = rand.Read(nonce)
+	return aead.Seal(nonce, nonce, plaintext, additionalData)
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	nonce, c := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, c, additionalData)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,85 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newMasterKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestLocalKMS_WrapKey(t *testing.T) {
+	kms, err := NewLocalKMS("2024-01", map[string][]byte{"2024-01": newMasterKey(t)})
+	assert.NoError(t, err)
+	dataKey := newMasterKey(t)
+
+	keyId, wrapped, err := kms.WrapKey(context.Background(), dataKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01", keyId)
+	assert.NotContains(t, string(wrapped), string(dataKey))
+
+	actual, err := kms.UnwrapKey(context.Background(), keyId, wrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, dataKey, actual)
+}
+
+func TestLocalKMS_UnwrapKey_Invalid(t *testing.T) {
+	kms, _ := NewLocalKMS("2024-01", map[string][]byte{"2023-01": newMasterKey(t), "2024-01": newMasterKey(t)})
+	_, wrapped, _ := kms.WrapKey(context.Background(), newMasterKey(t))
+
+	_, err := kms.UnwrapKey(context.Background(), "2023-01", wrapped)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = kms.UnwrapKey(context.Background(), "2025-01", wrapped)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = kms.UnwrapKey(context.Background(), "2024-01", wrapped[:4])
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestNewLocalKMS_Invalid(t *testing.T) {
+	_, err := NewLocalKMS("2024-01", map[string][]byte{"2024-01": []byte("short")})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = NewLocalKMS("2024-01", map[string][]byte{})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// unwrap only
+	_, err = NewLocalKMS("", map[string][]byte{})
+	assert.NoError(t, err)
+}
+
+func TestLoadLocalKMS(t *testing.T) {
+	dir := t.TempDir()
+	key := newMasterKey(t)
+	_ = os.WriteFile(filepath.Join(dir, "2024-01.key"), []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600)
+
+	kms, err := LoadLocalKMS("2024-01", dir)
+
+	assert.NoError(t, err)
+	expected, _ := NewLocalKMS("2024-01", map[string][]byte{"2024-01": key})
+	_, wrapped, _ := kms.WrapKey(context.Background(), key)
+	actual, err := expected.UnwrapKey(context.Background(), "2024-01", wrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, key, actual)
+}
+
+func TestLoadLocalKMS_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "2024-01.key"), []byte("not base64!"), 0o600)
+
+	_, err := LoadLocalKMS("2024-01", dir)
+
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,195 @@
+package expiry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"gics-to-kafka/pkg/notification"
+	bolt "go.etcd.io/bbolt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// timers ordered by expiry
+	timerBucket = []byte("timers")
+	// record key to timer key index
+	keyBucket = []byte("keys")
+)
+
+// Timer is a scheduled consent expiry
+type Timer struct {
+	Key       string                        `json:"key"`
+	Data      notification.NotificationData `json:"data"`
+	ExpiresAt time.Time                     `json:"expiresAt"`
+	// topic, record key and headers of the consent's notifications
+	Topic     string            `json:"topic,omitempty"`
+	RecordKey []byte            `json:"recordKey,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// Scheduler persists consent expiry timers, so they survive restarts
+type Scheduler struct {
+	db *bolt.DB
+}
+
+func Open(path string) (*Scheduler, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{timerBucket, keyBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Scheduler{db: db}, nil
+}
+
+func (s *Scheduler) Close() error {
+	return s.db.Close()
+}
+
+// ExpiresAt computes a consent's expiry from its template's expiration rule.
+// The earlier one is used if both a fixed date and a period are set.
+func ExpiresAt(consentDate time.Time, e *notification.TemplateExpiration, parse func(string) (time.Time, error)) (time.Time, bool, error) {
+	if e == nil || (e.FixedDate == "" && e.Period == "") {
+		return time.Time{}, false, nil
+	}
+
+	var expiresAt time.Time
+	if e.Period != "" {
+		t, err := AddPeriod(consentDate, e.Period)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		expiresAt = t
+	}
+	if e.FixedDate != "" {
+		t, err := parse(e.FixedDate)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if expiresAt.IsZero() || t.Before(expiresAt) {
+			expiresAt = t
+		}
+	}
+	return expiresAt, true, nil
+}
+
+// Schedule adds the timer or replaces an existing timer with the same key
+func (s *Scheduler) Schedule(t Timer) error {
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := cancel(tx, t.Key); err != nil {
+			return err
+		}
+
+		k := timerKey(t.ExpiresAt, t.Key)
+		if err := tx.Bucket(timerBucket).Put(k, v); err != nil {
+			return err
+		}
+		return tx.Bucket(keyBucket).Put([]byte(t.Key), k)
+	})
+}
+
+// Cancel removes the timer with the key, if any
+func (s *Scheduler) Cancel(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return cancel(tx, key)
+	})
+}
+
+func cancel(tx *bolt.Tx, key string) error {
+	keys := tx.Bucket(keyBucket)
+	k := keys.Get([]byte(key))
+	if k == nil {
+		return nil
+	}
+	if err := tx.Bucket(timerBucket).Delete(k); err != nil {
+		return err
+	}
+	return keys.Delete([]byte(key))
+}
+
+// Due returns all timers expiring at or before now, ordered by expiry
+func (s *Scheduler) Due(now time.Time) ([]Timer, error) {
+	var timers []Timer
+	end := timerKey(now, "\xff")
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(timerBucket).Cursor()
+		for k, v := c.First(); k != nil && bytes.Compare(k, end) <= 0; k, v = c.Next() {
+			var t Timer
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			timers = append(timers, t)
+		}
+		return nil
+	})
+
+	return timers, err
+}
+
+// Run calls expire for each due timer every interval until the context is
+// done. Timers are removed after they expired successfully, otherwise they
+// are retried with the next run.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration, expire func(context.Context, Timer) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.expireDue(ctx, expire)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) expireDue(ctx context.Context, expire func(context.Context, Timer) error) {
+	timers, err := s.Due(time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read consent expiry timers", "error", err)
+		return
+	}
+
+	for _, t := range timers {
+		if err = expire(ctx, t); err != nil {
+			slog.WarnContext(ctx, "Failed to expire consent, retrying with next run", "error", err)
+			continue
+		}
+		if err = s.Cancel(t.Key); err != nil {
+			slog.ErrorContext(ctx, "Failed to remove consent expiry timer", "error", err)
+		}
+	}
+}
+
+// timerKey orders timers by expiry
+func timerKey(expiresAt time.Time, key string) []byte {
+	k := binary.BigEndian.AppendUint64(nil, uint64(expiresAt.UnixNano()))
+	return append(k, key...)
+}
@@ -0,0 +1,142 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestScheduler(t *testing.T) *Scheduler {
+	s, err := Open(filepath.Join(t.TempDir(), "expiry.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestScheduler_Due(t *testing.T) {
+	s := openTestScheduler(t)
+	now := time.Now()
+
+	_ = s.Schedule(Timer{Key: "b", ExpiresAt: now.Add(-time.Minute)})
+	_ = s.Schedule(Timer{Key: "a", ExpiresAt: now.Add(-time.Hour)})
+	_ = s.Schedule(Timer{Key: "c", ExpiresAt: now.Add(time.Hour)})
+
+	actual, err := s.Due(now)
+
+	assert.NoError(t, err)
+	if assert.Len(t, actual, 2) {
+		assert.Equal(t, "a", actual[0].Key)
+		assert.Equal(t, "b", actual[1].Key)
+	}
+}
+
+func TestScheduler_ScheduleReplaces(t *testing.T) {
+	s := openTestScheduler(t)
+	now := time.Now()
+
+	_ = s.Schedule(Timer{Key: "a", ExpiresAt: now.Add(-time.Hour)})
+	_ = s.Schedule(Timer{Key: "a", ExpiresAt: now.Add(time.Hour)})
+
+	actual, _ := s.Due(now)
+	assert.Empty(t, actual)
+
+	actual, _ = s.Due(now.Add(2 * time.Hour))
+	assert.Len(t, actual, 1)
+}
+
+func TestScheduler_Cancel(t *testing.T) {
+	s := openTestScheduler(t)
+	now := time.Now()
+
+	_ = s.Schedule(Timer{Key: "a", ExpiresAt: now.Add(-time.Hour)})
+
+	assert.NoError(t, s.Cancel("a"))
+	assert.NoError(t, s.Cancel("unknown"))
+
+	actual, _ := s.Due(now)
+	assert.Empty(t, actual)
+}
+
+func TestScheduler_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expiry.db")
+	s, _ := Open(path)
+	domain := "MII"
+	_ = s.Schedule(Timer{Key: "a", Data: notification.NotificationData{ConsentKey: &notification.ConsentKey{ConsentTemplateKey: &notification.ConsentTemplateKey{DomainName: &domain}}}, ExpiresAt: time.Now()})
+	_ = s.Close()
+
+	s, _ = Open(path)
+	defer func() { _ = s.Close() }()
+	actual, _ := s.Due(time.Now())
+
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "MII", *actual[0].Data.ConsentKey.ConsentTemplateKey.DomainName)
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := openTestScheduler(t)
+	_ = s.Schedule(Timer{Key: "a", ExpiresAt: time.Now().Add(-time.Hour)})
+	_ = s.Schedule(Timer{Key: "b", ExpiresAt: time.Now().Add(-time.Hour)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var expired []string
+	s.Run(ctx, time.Hour, func(_ context.Context, timer Timer) error {
+		expired = append(expired, timer.Key)
+		if timer.Key == "b" {
+			cancel()
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"a", "b"}, expired)
+	// failed expiration is retried
+	actual, _ := s.Due(time.Now())
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "b", actual[0].Key)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	consentDate := time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)
+	parse := func(s string) (time.Time, error) { return time.Parse(time.RFC3339, s) }
+
+	cases := []struct {
+		name       string
+		expiration *notification.TemplateExpiration
+		expected   time.Time
+		ok         bool
+	}{
+		{"none", nil, time.Time{}, false},
+		{"empty", &notification.TemplateExpiration{}, time.Time{}, false},
+		{"period", &notification.TemplateExpiration{Period: "P5Y"}, time.Date(2028, 5, 2, 0, 0, 0, 0, time.UTC), true},
+		{"fixed", &notification.TemplateExpiration{FixedDate: "2025-01-01T00:00:00Z"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"earliest", &notification.TemplateExpiration{FixedDate: "2030-01-01T00:00:00Z", Period: "P1Y"}, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, ok, err := ExpiresAt(consentDate, c.expiration, parse)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.ok, ok)
+			assert.True(t, c.expected.Equal(actual))
+		})
+	}
+}
+
+func TestExpiresAt_Invalid(t *testing.T) {
+	parse := func(s string) (time.Time, error) { return time.Parse(time.RFC3339, s) }
+
+	_, _, err := ExpiresAt(time.Now(), &notification.TemplateExpiration{Period: "30Y"}, parse)
+	assert.Error(t, err)
+
+	_, _, err = ExpiresAt(time.Now(), &notification.TemplateExpiration{FixedDate: "31.12.2030"}, parse)
+	assert.Error(t, err)
+}
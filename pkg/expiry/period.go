@@ -0,0 +1,28 @@
+package expiry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var periodPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// AddPeriod adds an ISO 8601 period (e.g. P1Y6M, P30D, PT12H) to t
+func AddPeriod(t time.Time, period string) (time.Time, error) {
+	m := periodPattern.FindStringSubmatch(period)
+	if m == nil || period == "P" || period[len(period)-1] == 'T' {
+		return time.Time{}, fmt.Errorf("invalid period: %q", period)
+	}
+
+	v := make([]int, len(m)-1)
+	for i, s := range m[1:] {
+		if s != "" {
+			v[i], _ = strconv.Atoi(s)
+		}
+	}
+
+	return t.AddDate(v[0], v[1], v[2]*7+v[3]).
+		Add(time.Duration(v[4])*time.Hour + time.Duration(v[5])*time.Minute + time.Duration(v[6])*time.Second), nil
+}
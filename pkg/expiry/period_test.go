@@ -0,0 +1,38 @@
+package expiry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAddPeriod(t *testing.T) {
+	start := time.Date(2023, 5, 2, 1, 57, 27, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"P30Y":       time.Date(2053, 5, 2, 1, 57, 27, 0, time.UTC),
+		"P1Y6M":      time.Date(2024, 11, 2, 1, 57, 27, 0, time.UTC),
+		"P2W3D":      time.Date(2023, 5, 19, 1, 57, 27, 0, time.UTC),
+		"PT12H30M5S": time.Date(2023, 5, 2, 14, 27, 32, 0, time.UTC),
+		"P1DT1H":     time.Date(2023, 5, 3, 2, 57, 27, 0, time.UTC),
+	}
+
+	for period, expected := range cases {
+		t.Run(period, func(t *testing.T) {
+			actual, err := AddPeriod(start, period)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestAddPeriod_Invalid(t *testing.T) {
+	for _, period := range []string{"", "P", "P1", "1Y", "P1YT", "P1.5Y", "P1H"} {
+		t.Run(period, func(t *testing.T) {
+			_, err := AddPeriod(time.Now(), period)
+
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutcomeAccepted is the outcome of notifications sent to Kafka, otherwise
+// the outcome is the error code
+const OutcomeAccepted = "ACCEPTED"
+
+var ErrChainBroken = errors.New("journal chain broken")
+
+// Entry records a received notification and what was done with it
+type Entry struct {
+	Seq         uint64    `json:"seq"`
+	Time        time.Time `json:"time"`
+	User        string    `json:"user"`
+	ClientId    string    `json:"clientId"`
+	Type        string    `json:"type"`
+	ContentHash string    `json:"contentHash"`
+	Outcome     string    `json:"outcome"`
+	Topic       string    `json:"topic,omitempty"`
+	Partition   *int32    `json:"partition,omitempty"`
+	Offset      *int64    `json:"offset,omitempty"`
+	// Prev is the hash of the previous entry
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// hash chains the entry to the previous one
+func (e Entry) hash() string {
+	e.Hash = ""
+	b, _ := json.Marshal(e)
+	h := sha256.Sum256(append([]byte(e.Prev), b...))
+	return hex.EncodeToString(h[:])
+}
+
+// Journal is an append-only file of hash-chained entries, rotated by size.
+// The chain continues across rotated files.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+	seq     uint64
+	last    string
+	// error of the last append
+	err error
+}
+
+// Open continues the journal at path. Files are rotated when they exceed
+// maxSize bytes, unless maxSize is 0.
+func Open(path string, maxSize int64) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	files, err := Files(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// continue the chain from the last entry
+	j := &Journal{path: path, maxSize: maxSize}
+	for i := len(files) - 1; i >= 0 && j.seq == 0; i-- {
+		err = readFile(files[i], func(_ string, _ int, e Entry) error {
+			j.seq, j.last = e.Seq, e.Hash
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return j, j.open()
+}
+
+func (j *Journal) open() error {
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	j.file, j.size = f, info.Size()
+	return nil
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// Append chains the entry to the journal and writes it
+func (j *Journal) Append(e Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.err = j.append(e)
+	return j.err
+}
+
+// Err returns the error of the last append, nil if it succeeded
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+func (j *Journal) append(e Entry) error {
+	e.Seq = j.seq + 1
+	e.Time = e.Time.UTC()
+	e.Prev = j.last
+	e.Hash = e.hash()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if j.maxSize > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxSize {
+		if err = j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if err = j.file.Sync(); err != nil {
+		return err
+	}
+	j.seq, j.last = e.Seq, e.Hash
+	return nil
+}
+
+// rotate renames the current file, e.g. journal.jsonl to
+// journal-20240101T120000.000000000.jsonl, and starts a new one
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(j.path)
+	rotated := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(j.path, ext), time.Now().UTC().Format("20060102T150405.000000000"), ext)
+	if err := os.Rename(j.path, rotated); err != nil {
+		return err
+	}
+	return j.open()
+}
+
+// Files returns the rotated files of the journal in order followed by the
+// current file, if they exist
+func Files(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	rotated, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(rotated)
+
+	if _, err = os.Stat(path); err == nil {
+		return append(rotated, path), nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// Read calls handle with each entry of all journal files in order
+func Read(path string, handle func(file string, line int, e Entry) error) error {
+	files, err := Files(path)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err = readFile(f, handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(file string, handle func(file string, line int, e Entry) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; s.Scan(); line++ {
+		var e Entry
+		if err = json.Unmarshal(s.Bytes(), &e); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		if err = handle(file, line, e); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// Verify checks the hash chain of all journal files and returns the number
+// of verified entries
+func Verify(path string) (int, error) {
+	count := 0
+	var seq uint64
+	prev := ""
+
+	err := Read(path, func(file string, line int, e Entry) error {
+		switch {
+		case e.Seq != seq+1:
+			return fmt.Errorf("%s:%d: %w: expected sequence %d, got %d", file, line, ErrChainBroken, seq+1, e.Seq)
+		case e.Prev != prev:
+			return fmt.Errorf("%s:%d: %w: previous hash mismatch", file, line, ErrChainBroken)
+		case e.Hash != e.hash():
+			return fmt.Errorf("%s:%d: %w: entry hash mismatch", file, line, ErrChainBroken)
+		}
+		count++
+		seq, prev = e.Seq, e.Hash
+		return nil
+	})
+
+	return count, err
+}
+
+// Export writes all journal entries as CSV
+func Export(path string, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"seq", "time", "user", "clientId", "type", "contentHash", "outcome", "topic", "partition", "offset", "prev", "hash"})
+
+	err := Read(path, func(_ string, _ int, e Entry) error {
+		var partition, offset string
+		if e.Partition != nil {
+			partition = strconv.Itoa(int(*e.Partition))
+		}
+		if e.Offset != nil {
+			offset = strconv.FormatInt(*e.Offset, 10)
+		}
+		return cw.Write([]string{
+			strconv.FormatUint(e.Seq, 10), e.Time.Format(time.RFC3339Nano), e.User, e.ClientId, e.Type,
+			e.ContentHash, e.Outcome, e.Topic, partition, offset, e.Prev, e.Hash,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,167 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestJournal(t *testing.T, path string, maxSize int64) *Journal {
+	j, err := Open(path, maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = j.Close() })
+	return j
+}
+
+func testEntry(clientId string) Entry {
+	partition, offset := int32(0), int64(42)
+	return Entry{
+		Time:        time.Date(2023, 6, 5, 12, 9, 10, 0, time.UTC),
+		User:        "test",
+		ClientId:    clientId,
+		Type:        "GICS.AddConsent",
+		ContentHash: "abc",
+		Outcome:     OutcomeAccepted,
+		Topic:       "notifications",
+		Partition:   &partition,
+		Offset:      &offset,
+	}
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+
+	assert.NoError(t, j.Append(testEntry("gICS_Web")))
+	assert.NoError(t, j.Append(Entry{Time: time.Now(), ClientId: "gICS_Web", Outcome: "INVALID_DATA"}))
+
+	var entries []Entry
+	_ = Read(path, func(_ string, _ int, e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, uint64(1), entries[0].Seq)
+		assert.Empty(t, entries[0].Prev)
+		assert.Equal(t, uint64(2), entries[1].Seq)
+		assert.Equal(t, entries[0].Hash, entries[1].Prev)
+	}
+
+	count, err := Verify(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestAppend_Err(t *testing.T) {
+	j := openTestJournal(t, filepath.Join(t.TempDir(), "journal.jsonl"), 0)
+	assert.NoError(t, j.Append(testEntry("gICS_Web")))
+	assert.NoError(t, j.Err())
+
+	_ = j.file.Close()
+
+	assert.Error(t, j.Append(testEntry("gICS_Web")))
+	assert.Error(t, j.Err())
+}
+
+func TestOpen_ContinuesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, _ := Open(path, 0)
+	_ = j.Append(testEntry("gICS_Web"))
+	_ = j.Close()
+
+	j = openTestJournal(t, path, 0)
+	_ = j.Append(testEntry("gICS_Web"))
+
+	count, err := Verify(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestAppend_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 500)
+
+	for range 5 {
+		assert.NoError(t, j.Append(testEntry("gICS_Web")))
+	}
+
+	files, _ := Files(path)
+	assert.Greater(t, len(files), 1)
+	assert.Equal(t, path, files[len(files)-1])
+
+	count, err := Verify(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	// continues after rotation
+	_ = j.Close()
+	j = openTestJournal(t, path, 500)
+	_ = j.Append(testEntry("gICS_Web"))
+	count, err = Verify(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	cases := map[string]func(string) string{
+		"modified": func(s string) string {
+			return strings.Replace(s, `"outcome":"INVALID_DATA"`, `"outcome":"ACCEPTED"`, 1)
+		},
+		"removed": func(s string) string {
+			lines := strings.SplitAfter(s, "\n")
+			return lines[0] + lines[2]
+		},
+		"reordered": func(s string) string {
+			lines := strings.SplitAfter(s, "\n")
+			return lines[1] + lines[0] + lines[2]
+		},
+	}
+
+	for name, tamper := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "journal.jsonl")
+			j := openTestJournal(t, path, 0)
+			_ = j.Append(testEntry("gICS_Web"))
+			_ = j.Append(Entry{ClientId: "gICS_Web", Outcome: "INVALID_DATA"})
+			_ = j.Append(testEntry("gICS_Web"))
+
+			b, _ := os.ReadFile(path)
+			_ = os.WriteFile(path, []byte(tamper(string(b))), 0o600)
+
+			_, err := Verify(path)
+			assert.ErrorIs(t, err, ErrChainBroken)
+		})
+	}
+}
+
+func TestVerify_Empty(t *testing.T) {
+	count, err := Verify(filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+	_ = j.Append(testEntry("gICS_Web"))
+	_ = j.Append(Entry{Time: time.Date(2023, 6, 5, 12, 9, 11, 0, time.UTC), ClientId: "gICS_Web", Outcome: "INVALID_DATA"})
+
+	var out bytes.Buffer
+	err := Export(path, &out)
+
+	assert.NoError(t, err)
+	records, _ := csv.NewReader(&out).ReadAll()
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, []string{"seq", "time", "user", "clientId", "type", "contentHash", "outcome", "topic", "partition", "offset", "prev", "hash"}, records[0])
+		assert.Equal(t, []string{"1", "2023-06-05T12:09:10Z", "test", "gICS_Web", "GICS.AddConsent", "abc", "ACCEPTED", "notifications", "0", "42", ""}, records[1][:11])
+		assert.Equal(t, []string{"2", "2023-06-05T12:09:11Z", "", "gICS_Web", "", "", "INVALID_DATA", "", "", ""}, records[2][:10])
+	}
+}
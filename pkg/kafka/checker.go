@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"slices"
+)
+
+var ErrWriteNotAllowed = errors.New("write not allowed")
+
+type AdminInternal interface {
+	DescribeTopics(ctx context.Context, topics kafka.TopicCollection, options ...kafka.DescribeTopicsAdminOption) (kafka.DescribeTopicsResult, error)
+	Close()
+}
+
+// TopicChecker verifies that topics exist and may be written to
+type TopicChecker struct {
+	Admin AdminInternal
+}
+
+// NewTopicChecker creates an admin client with the producer's settings. Invalid
+// SSL settings, e.g. a wrong key password, already fail here.
+func NewTopicChecker(c config.Kafka) (*TopicChecker, error) {
+	cfg := clientConfig(c)
+	// not supported by the admin client
+	delete(*cfg, "go.logs.channel.enable")
+
+	a, err := kafka.NewAdminClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicChecker{Admin: a}, nil
+}
+
+// Check returns the error of each described topic. The write permission is only verified
+// if the cluster reports the authorized operations, i.e. an authorizer is configured.
+func (t *TopicChecker) Check(ctx context.Context, topics []string) (map[string]error, error) {
+	res, err := t.Admin.DescribeTopics(ctx, kafka.NewTopicCollectionOfTopicNames(topics),
+		kafka.SetAdminOptionIncludeAuthorizedOperations(true))
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make(map[string]error, len(res.TopicDescriptions))
+	for _, d := range res.TopicDescriptions {
+		switch {
+		case d.Error.Code() != kafka.ErrNoError:
+			errs[d.Name] = fmt.Errorf("topic %s not available: %v", d.Name, d.Error)
+		case d.AuthorizedOperations != nil &&
+			!slices.Contains(d.AuthorizedOperations, kafka.ACLOperationWrite) &&
+			!slices.Contains(d.AuthorizedOperations, kafka.ACLOperationAll):
+			errs[d.Name] = fmt.Errorf("topic %s: %w", d.Name, ErrWriteNotAllowed)
+		default:
+			errs[d.Name] = nil
+		}
+	}
+	return errs, nil
+}
+
+func (t *TopicChecker) Close() {
+	t.Admin.Close()
+}
@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type TestAdmin struct {
+	result kafka.DescribeTopicsResult
+	err    error
+	closed bool
+}
+
+func (a *TestAdmin) DescribeTopics(_ context.Context, _ kafka.TopicCollection, _ ...kafka.DescribeTopicsAdminOption) (kafka.DescribeTopicsResult, error) {
+	return a.result, a.err
+}
+
+func (a *TestAdmin) Close() {
+	a.closed = true
+}
+
+func TestTopicChecker_Check(t *testing.T) {
+	a := &TestAdmin{result: kafka.DescribeTopicsResult{TopicDescriptions: []kafka.TopicDescription{
+		{Name: "writable", AuthorizedOperations: []kafka.ACLOperation{kafka.ACLOperationRead, kafka.ACLOperationWrite}},
+		{Name: "all", AuthorizedOperations: []kafka.ACLOperation{kafka.ACLOperationAll}},
+		{Name: "no-authorizer"},
+		{Name: "read-only", AuthorizedOperations: []kafka.ACLOperation{kafka.ACLOperationRead}},
+		{Name: "missing", Error: kafka.NewError(kafka.ErrUnknownTopicOrPart, "unknown topic", false)},
+	}}}
+	c := &TopicChecker{Admin: a}
+
+	actual, err := c.Check(context.Background(), []string{"writable", "all", "no-authorizer", "read-only", "missing", "other"})
+	c.Close()
+
+	assert.NoError(t, err)
+	assert.NoError(t, actual["writable"])
+	assert.NoError(t, actual["all"])
+	assert.NoError(t, actual["no-authorizer"])
+	assert.ErrorIs(t, actual["read-only"], ErrWriteNotAllowed)
+	assert.ErrorContains(t, actual["missing"], "not available")
+	assert.NotContains(t, actual, "other")
+	assert.True(t, a.closed)
+}
+
+func TestTopicChecker_CheckError(t *testing.T) {
+	c := &TopicChecker{Admin: &TestAdmin{err: errors.New("timed out")}}
+
+	_, err := c.Check(context.Background(), []string{"test"})
+
+	assert.Error(t, err)
+}
+
+func TestNewTopicChecker_InvalidSsl(t *testing.T) {
+	_, err := NewTopicChecker(config.Kafka{
+		BootstrapServers: "localhost:9092",
+		SecurityProtocol: "ssl",
+		Ssl:              config.Ssl{CaLocation: "/does/not/exist.pem"},
+	})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package kafka
+
+import "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+// headerCarrier adapts Kafka record headers to the OpenTelemetry TextMapCarrier
+type headerCarrier []kafka.Header
+
+func (h *headerCarrier) Get(key string) string {
+	for _, v := range *h {
+		if v.Key == key {
+			return string(v.Value)
+		}
+	}
+	return ""
+}
+
+func (h *headerCarrier) Set(key, value string) {
+	for i, v := range *h {
+		if v.Key == key {
+			(*h)[i].Value = []byte(value)
+			return
+		}
+	}
+	*h = append(*h, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (h *headerCarrier) Keys() []string {
+	keys := make([]string, len(*h))
+	for i, v := range *h {
+		keys[i] = v.Key
+	}
+	return keys
+}
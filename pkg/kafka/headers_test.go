@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestHeaderCarrier(t *testing.T) {
+	var h headerCarrier
+
+	h.Set("a", "1")
+	h.Set("b", "2")
+	h.Set("a", "3")
+
+	assert.Equal(t, "3", h.Get("a"))
+	assert.Equal(t, "2", h.Get("b"))
+	assert.Equal(t, "", h.Get("c"))
+	assert.Equal(t, []string{"a", "b"}, h.Keys())
+}
+
+func TestSend_TraceContextHeader(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	k := &RecordingKafkaProducer{}
+	p := &NotificationProducer{Producer: k, Topic: "test"}
+
+	p.Send(ctx, Record{Key: []byte("key")}, nil)
+
+	h := headerCarrier(k.messages[0].Headers)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", h.Get("traceparent"))
+}
@@ -2,40 +2,69 @@ package kafka
 
 import (
 	"context"
+	"encoding/json"
 	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/correlation"
+	"gics-to-kafka/pkg/signing"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
+// queueFullWait before retrying to produce to a full producer queue
+var queueFullWait = time.Second
+
 type ProducerInternal interface {
 	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
 	IsClosed() bool
 	GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error)
+	Len() int
+}
+
+// Record to be produced. Defaults to the producer's topic if Topic is empty.
+// A nil Value produces a tombstone.
+type Record struct {
+	Topic     string
+	Key       []byte
+	Timestamp time.Time
+	Value     []byte
+	Headers   map[string]string
 }
 
 type Producer interface {
-	Send(key []byte, timestamp time.Time, msg []byte, deliveryChan chan kafka.Event)
+	Send(ctx context.Context, r Record, deliveryChan chan kafka.Event)
 	IsHealthy() bool
 }
 
+// Inspector exposes producer internals for operations
+type Inspector interface {
+	// QueueLength returns the number of records waiting to be sent or acknowledged
+	QueueLength() int
+	// Stats returns the latest librdkafka statistics, nil if not enabled
+	Stats() json.RawMessage
+	// Metadata returns the cluster's brokers and topics
+	Metadata() (*kafka.Metadata, error)
+}
+
 type NotificationProducer struct {
 	Producer ProducerInternal
 	Topic    string
+	// signs all records if set
+	Signer *signing.Signer
+	stats  atomic.Pointer[string]
 }
 
 func NewProducer(config config.Kafka) *NotificationProducer {
-	p, err := kafka.NewProducer(&kafka.ConfigMap{
-		"bootstrap.servers":        config.BootstrapServers,
-		"security.protocol":        config.SecurityProtocol,
-		"ssl.ca.location":          config.Ssl.CaLocation,
-		"ssl.key.location":         config.Ssl.KeyLocation,
-		"ssl.certificate.location": config.Ssl.CertificateLocation,
-		"ssl.key.password":         config.Ssl.KeyPassword,
-		"log.connection.close":     false,
-		"go.logs.channel.enable":   true,
-	})
+	cfg := clientConfig(config)
+	if config.StatisticsInterval > 0 {
+		_ = cfg.SetKey("statistics.interval.ms", int(config.StatisticsInterval.Milliseconds()))
+	}
+	p, err := kafka.NewProducer(cfg)
 	if err != nil {
 		slog.Error("Failed to create Kafka producer. Terminating")
 		os.Exit(1)
@@ -48,31 +77,98 @@ func NewProducer(config config.Kafka) *NotificationProducer {
 		}
 	}()
 
-	return &NotificationProducer{
+	np := &NotificationProducer{
 		Producer: p,
 		Topic:    config.OutputTopic,
 	}
+	go func() {
+		for e := range p.Events() {
+			np.handleEvent(e)
+		}
+	}()
+	if config.Signing.Enabled {
+		np.Signer, err = signing.LoadSigner(config.Signing.KeyId, config.Signing.KeyFile)
+		if err != nil {
+			slog.Error("Failed to load signing key. Terminating", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Signing records", "keyId", np.Signer.KeyId())
+	}
+	return np
 }
 
-func (p *NotificationProducer) Send(key []byte, timestamp time.Time, msg []byte, deliveryChan chan kafka.Event) {
+func (p *NotificationProducer) Send(ctx context.Context, r Record, deliveryChan chan kafka.Event) {
+	topic := r.Topic
+	if topic == "" {
+		topic = p.Topic
+	}
 
-	err := p.Producer.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &p.Topic, Partition: kafka.PartitionAny},
-		Key:            key,
-		Timestamp:      timestamp,
-		Value:          msg,
-	}, deliveryChan)
-	if err != nil {
-		if err.(kafka.Error).Code() == kafka.ErrQueueFull {
-			// producer queue is full, wait 1s for messages
+	var headers headerCarrier
+	for _, k := range slices.Sorted(maps.Keys(r.Headers)) {
+		headers.Set(k, r.Headers[k])
+	}
+	if p.Signer != nil {
+		// the signed timestamp must be the stored one
+		if r.Timestamp.IsZero() {
+			r.Timestamp = time.Now()
+		}
+		r.Timestamp = r.Timestamp.Truncate(time.Millisecond)
+		headers.Set(signing.Header, p.Signer.Sign(r.Key, r.Value, r.Timestamp))
+	}
+	if id := correlation.FromContext(ctx); id != "" {
+		headers.Set(correlation.Header, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, &headers)
+
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            r.Key,
+		Timestamp:      r.Timestamp,
+		Value:          r.Value,
+		Headers:        headers,
+	}
+	for {
+		err := p.Producer.Produce(msg, deliveryChan)
+		if err == nil {
+			return
+		}
+		if err.(kafka.Error).Code() == kafka.ErrQueueFull && ctx.Err() == nil {
+			// producer queue is full, wait for messages
 			// to be delivered then try again.
-			time.Sleep(time.Second)
-			p.Send(key, timestamp, msg, deliveryChan)
+			time.Sleep(queueFullWait)
+			continue
 		}
 		deliveryChan <- err.(kafka.Error)
+		return
+	}
+}
+
+// handleEvent handles producer events other than delivery reports
+func (p *NotificationProducer) handleEvent(e kafka.Event) {
+	switch ev := e.(type) {
+	case *kafka.Stats:
+		s := ev.String()
+		p.stats.Store(&s)
+	case kafka.Error:
+		slog.Warn("Kafka producer error", "error", ev)
 	}
 }
 
+func (p *NotificationProducer) QueueLength() int {
+	return p.Producer.Len()
+}
+
+func (p *NotificationProducer) Stats() json.RawMessage {
+	if s := p.stats.Load(); s != nil {
+		return json.RawMessage(*s)
+	}
+	return nil
+}
+
+func (p *NotificationProducer) Metadata() (*kafka.Metadata, error) {
+	return p.Producer.GetMetadata(nil, true, 5000)
+}
+
 func (p *NotificationProducer) IsHealthy() bool {
 	if p.Producer != nil && !p.Producer.IsClosed() {
 		_, err := p.Producer.GetMetadata(&p.Topic, false, 5000)
@@ -81,6 +177,19 @@ func (p *NotificationProducer) IsHealthy() bool {
 	return false
 }
 
+func clientConfig(config config.Kafka) *kafka.ConfigMap {
+	return &kafka.ConfigMap{
+		"bootstrap.servers":        config.BootstrapServers,
+		"security.protocol":        config.SecurityProtocol,
+		"ssl.ca.location":          config.Ssl.CaLocation,
+		"ssl.key.location":         config.Ssl.KeyLocation,
+		"ssl.certificate.location": config.Ssl.CertificateLocation,
+		"ssl.key.password":         config.Ssl.KeyPassword,
+		"log.connection.close":     false,
+		"go.logs.channel.enable":   true,
+	}
+}
+
 func mapSyslogLevel(level int) slog.Level {
 	// syslog levels
 	switch {
@@ -1,7 +1,12 @@
 package kafka
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/correlation"
+	"gics-to-kafka/pkg/signing"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/stretchr/testify/assert"
 	"log/slog"
@@ -48,6 +53,10 @@ func (t TestKafkaProducer) GetMetadata(_ *string, _ bool, _ int) (*kafka.Metadat
 	return &kafka.Metadata{}, nil
 }
 
+func (t TestKafkaProducer) Len() int {
+	return 3
+}
+
 func TestNewProducer(t *testing.T) {
 	cfg := config.Kafka{
 		BootstrapServers: "",
@@ -65,13 +74,109 @@ func TestSend_Error(t *testing.T) {
 	channel := make(chan kafka.Event)
 
 	// just empty data, we rely on Produce of TestKafkaProducer to return an error
-	go p.Send([]byte{}, time.Time{}, []byte{}, channel)
+	go p.Send(context.Background(), Record{}, channel)
 
 	actual := <-channel
 
 	assert.Equal(t, kafka.NewError(42, "test", true), actual)
 }
 
+type QueueFullKafkaProducer struct {
+	TestKafkaProducer
+	full int
+}
+
+func (q *QueueFullKafkaProducer) Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	if q.full > 0 {
+		q.full--
+		return kafka.NewError(kafka.ErrQueueFull, "queue full", false)
+	}
+	deliveryChan <- msg
+	return nil
+}
+
+func TestSend_QueueFull(t *testing.T) {
+	queueFullWait = time.Millisecond
+	t.Cleanup(func() { queueFullWait = time.Second })
+	p := &NotificationProducer{Producer: &QueueFullKafkaProducer{full: 2}}
+	channel := make(chan kafka.Event, 1)
+
+	done := make(chan struct{})
+	go func() {
+		p.Send(context.Background(), Record{Topic: "test"}, channel)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send blocked after retrying")
+	}
+	assert.IsType(t, &kafka.Message{}, <-channel)
+	assert.Empty(t, channel)
+}
+
+func TestSend_QueueFullCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &NotificationProducer{Producer: &QueueFullKafkaProducer{full: 1}}
+	channel := make(chan kafka.Event, 1)
+
+	p.Send(ctx, Record{Topic: "test"}, channel)
+
+	assert.Equal(t, kafka.ErrQueueFull, (<-channel).(kafka.Error).Code())
+}
+
+type RecordingKafkaProducer struct {
+	TestKafkaProducer
+	messages []*kafka.Message
+}
+
+func (r *RecordingKafkaProducer) Produce(msg *kafka.Message, _ chan kafka.Event) error {
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func TestSend_CorrelationHeader(t *testing.T) {
+	k := &RecordingKafkaProducer{}
+	p := &NotificationProducer{Producer: k, Topic: "test"}
+
+	p.Send(correlation.NewContext(context.Background(), "4711"), Record{Key: []byte("key")}, nil)
+	p.Send(context.Background(), Record{Key: []byte("key")}, nil)
+
+	assert.Equal(t, []kafka.Header{{Key: "X-Request-ID", Value: []byte("4711")}}, k.messages[0].Headers)
+	assert.Empty(t, k.messages[1].Headers)
+}
+
+func TestSend_RecordHeaders(t *testing.T) {
+	k := &RecordingKafkaProducer{}
+	p := &NotificationProducer{Producer: k, Topic: "test"}
+
+	p.Send(correlation.NewContext(context.Background(), "4711"), Record{Headers: map[string]string{"b": "2", "a": "1"}}, nil)
+
+	assert.Equal(t, []kafka.Header{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+		{Key: "X-Request-ID", Value: []byte("4711")},
+	}, k.messages[0].Headers)
+}
+
+func TestSend_Topic(t *testing.T) {
+	k := &RecordingKafkaProducer{}
+	p := &NotificationProducer{Producer: k, Topic: "default"}
+	ts := time.Now()
+
+	p.Send(context.Background(), Record{Key: []byte("key"), Timestamp: ts, Value: []byte("value")}, nil)
+	p.Send(context.Background(), Record{Topic: "snapshot", Key: []byte("key")}, nil)
+
+	assert.Equal(t, "default", *k.messages[0].TopicPartition.Topic)
+	assert.Equal(t, []byte("key"), k.messages[0].Key)
+	assert.Equal(t, ts, k.messages[0].Timestamp)
+	assert.Equal(t, []byte("value"), k.messages[0].Value)
+	assert.Equal(t, "snapshot", *k.messages[1].TopicPartition.Topic)
+	assert.Nil(t, k.messages[1].Value)
+}
+
 func TestMapSyslogLevel(t *testing.T) {
 	cases := []LogLevelTestCase{
 		{
@@ -105,3 +210,36 @@ func TestMapSyslogLevel(t *testing.T) {
 	}
 
 }
+
+func TestSend_Signature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	k := &RecordingKafkaProducer{}
+	p := &NotificationProducer{Producer: k, Topic: "test", Signer: signing.NewSigner("2024-01", priv)}
+
+	p.Send(context.Background(), Record{Key: []byte("key"), Timestamp: time.Now(), Value: []byte("value")}, nil)
+	p.Send(context.Background(), Record{Key: []byte("key")}, nil)
+
+	v := signing.NewVerifier(map[string]ed25519.PublicKey{"2024-01": pub})
+	for _, m := range k.messages {
+		if assert.Len(t, m.Headers, 1) {
+			assert.Equal(t, signing.Header, m.Headers[0].Key)
+			assert.NoError(t, v.Verify(string(m.Headers[0].Value), m.Key, m.Value, m.Timestamp))
+		}
+	}
+	assert.False(t, k.messages[1].Timestamp.IsZero())
+}
+
+func TestInspector(t *testing.T) {
+	p := &NotificationProducer{Producer: TestKafkaProducer{}, Topic: "test"}
+
+	assert.Equal(t, 3, p.QueueLength())
+	assert.Nil(t, p.Stats())
+
+	p.handleEvent(&kafka.Stats{})
+	p.handleEvent(kafka.NewError(kafka.ErrAllBrokersDown, "down", false))
+	assert.NotNil(t, p.Stats())
+
+	md, err := p.Metadata()
+	assert.NoError(t, err)
+	assert.NotNil(t, md)
+}
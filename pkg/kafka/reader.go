@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"time"
+)
+
+const readTimeoutMs = 10000
+
+// defaultReadDeadline limits reading a whole topic
+const defaultReadDeadline = 10 * time.Minute
+
+type ConsumerInternal interface {
+	GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error)
+	QueryWatermarkOffsets(topic string, partition int32, timeoutMs int) (low, high int64, err error)
+	Assign(partitions []kafka.TopicPartition) error
+	Poll(timeoutMs int) kafka.Event
+	Position(partitions []kafka.TopicPartition) ([]kafka.TopicPartition, error)
+	Close() error
+}
+
+// TopicReader reads all records of a topic up to its current end
+type TopicReader struct {
+	Topic string
+	// Deadline limits reading the topic, the read fails if it is exceeded
+	Deadline    time.Duration
+	newConsumer func() (ConsumerInternal, error)
+}
+
+func NewTopicReader(c config.Kafka, topic string) *TopicReader {
+	return &TopicReader{
+		Topic:    topic,
+		Deadline: defaultReadDeadline,
+		newConsumer: func() (ConsumerInternal, error) {
+			cfg := clientConfig(c)
+			_ = cfg.SetKey("group.id", "gics-to-kafka-reader")
+			_ = cfg.SetKey("enable.auto.commit", false)
+			_ = cfg.SetKey("go.logs.channel.enable", false)
+
+			return kafka.NewConsumer(cfg)
+		},
+	}
+}
+
+// ReadAll passes all records up to the high watermarks at the start to handle.
+// A partition is complete once the consumer's position reaches its high
+// watermark, which also skips compacted offsets and transaction markers.
+func (r *TopicReader) ReadAll(handle func(value []byte, timestamp time.Time) error) error {
+	c, err := r.newConsumer()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	md, err := c.GetMetadata(&r.Topic, false, readTimeoutMs)
+	if err != nil {
+		return err
+	}
+	t, ok := md.Topics[r.Topic]
+	if !ok || t.Error.Code() != kafka.ErrNoError {
+		return fmt.Errorf("topic %s not available: %v", r.Topic, t.Error)
+	}
+
+	// read each partition up to its current high watermark
+	remaining := make(map[int32]int64)
+	var partitions []kafka.TopicPartition
+	for _, p := range t.Partitions {
+		low, high, err := c.QueryWatermarkOffsets(r.Topic, p.ID, readTimeoutMs)
+		if err != nil {
+			return err
+		}
+		if high > low {
+			remaining[p.ID] = high
+			partitions = append(partitions, kafka.TopicPartition{Topic: &r.Topic, Partition: p.ID, Offset: kafka.Offset(low)})
+		}
+	}
+	if err = c.Assign(partitions); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(r.Deadline)
+	for len(remaining) > 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("reading topic %s exceeded the deadline of %s, %d partitions incomplete", r.Topic, r.Deadline, len(remaining))
+		}
+
+		switch e := c.Poll(1000).(type) {
+		case *kafka.Message:
+			if err = handle(e.Value, e.Timestamp); err != nil {
+				return err
+			}
+		case kafka.Error:
+			if e.IsFatal() {
+				return e
+			}
+			slog.Warn("Error reading topic", "topic", r.Topic, "error", e)
+		}
+
+		if err = r.complete(c, remaining); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// complete removes the partitions whose position reached the high watermark
+func (r *TopicReader) complete(c ConsumerInternal, remaining map[int32]int64) error {
+	partitions := make([]kafka.TopicPartition, 0, len(remaining))
+	for p := range remaining {
+		partitions = append(partitions, kafka.TopicPartition{Topic: &r.Topic, Partition: p})
+	}
+	positions, err := c.Position(partitions)
+	if err != nil {
+		return err
+	}
+	for _, p := range positions {
+		// the position is invalid until the first fetch
+		if p.Offset >= 0 && int64(p.Offset) >= remaining[p.Partition] {
+			delete(remaining, p.Partition)
+		}
+	}
+	return nil
+}
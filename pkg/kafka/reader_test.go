@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type TestConsumer struct {
+	metadata  *kafka.Metadata
+	offsets   map[int32][2]int64
+	events    []kafka.Event
+	assigned  []kafka.TopicPartition
+	positions map[int32]int64
+	closed    bool
+}
+
+// skipEvent advances the position without a message, like a transaction marker
+type skipEvent struct {
+	partition int32
+	offset    int64
+}
+
+func (e skipEvent) String() string {
+	return "skip"
+}
+
+func (c *TestConsumer) GetMetadata(_ *string, _ bool, _ int) (*kafka.Metadata, error) {
+	return c.metadata, nil
+}
+
+func (c *TestConsumer) QueryWatermarkOffsets(_ string, partition int32, _ int) (int64, int64, error) {
+	o := c.offsets[partition]
+	return o[0], o[1], nil
+}
+
+func (c *TestConsumer) Assign(partitions []kafka.TopicPartition) error {
+	c.assigned = partitions
+	return nil
+}
+
+func (c *TestConsumer) Poll(_ int) kafka.Event {
+	if len(c.events) == 0 {
+		return nil
+	}
+	e := c.events[0]
+	c.events = c.events[1:]
+	if c.positions == nil {
+		c.positions = make(map[int32]int64)
+	}
+	switch e := e.(type) {
+	case *kafka.Message:
+		c.positions[e.TopicPartition.Partition] = int64(e.TopicPartition.Offset) + 1
+	case skipEvent:
+		c.positions[e.partition] = e.offset
+		return nil
+	}
+	return e
+}
+
+func (c *TestConsumer) Position(partitions []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	for i, p := range partitions {
+		partitions[i].Offset = kafka.OffsetInvalid
+		if o, ok := c.positions[p.Partition]; ok {
+			partitions[i].Offset = kafka.Offset(o)
+		}
+	}
+	return partitions, nil
+}
+
+func (c *TestConsumer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func testMessage(partition int32, offset int64, value string) *kafka.Message {
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Partition: partition, Offset: kafka.Offset(offset)},
+		Value:          []byte(value),
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	c := &TestConsumer{
+		metadata: &kafka.Metadata{Topics: map[string]kafka.TopicMetadata{
+			"test": {Topic: "test", Partitions: []kafka.PartitionMetadata{{ID: 0}, {ID: 1}, {ID: 2}}},
+		}},
+		offsets: map[int32][2]int64{0: {0, 2}, 1: {5, 6}, 2: {3, 3}},
+		events: []kafka.Event{
+			testMessage(0, 0, "a"),
+			kafka.NewError(kafka.ErrTransport, "test", false),
+			testMessage(1, 5, "b"),
+			testMessage(0, 1, "c"),
+		},
+	}
+	r := &TopicReader{Topic: "test", Deadline: time.Second, newConsumer: func() (ConsumerInternal, error) { return c, nil }}
+
+	var actual []string
+	err := r.ReadAll(func(value []byte, _ time.Time) error {
+		actual = append(actual, string(value))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, actual)
+	assert.Len(t, c.assigned, 2)
+	assert.True(t, c.closed)
+}
+
+func TestReadAll_SkippedOffsets(t *testing.T) {
+	c := &TestConsumer{
+		metadata: &kafka.Metadata{Topics: map[string]kafka.TopicMetadata{
+			"test": {Topic: "test", Partitions: []kafka.PartitionMetadata{{ID: 0}}},
+		}},
+		offsets: map[int32][2]int64{0: {0, 4}},
+		// offset 1 was compacted, offset 3 is a transaction marker
+		events: []kafka.Event{testMessage(0, 0, "a"), testMessage(0, 2, "b"), skipEvent{partition: 0, offset: 4}},
+	}
+	r := &TopicReader{Topic: "test", Deadline: time.Second, newConsumer: func() (ConsumerInternal, error) { return c, nil }}
+
+	var actual []string
+	err := r.ReadAll(func(value []byte, _ time.Time) error {
+		actual = append(actual, string(value))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, actual)
+}
+
+func TestReadAll_Deadline(t *testing.T) {
+	c := &TestConsumer{
+		metadata: &kafka.Metadata{Topics: map[string]kafka.TopicMetadata{
+			"test": {Topic: "test", Partitions: []kafka.PartitionMetadata{{ID: 0}}},
+		}},
+		offsets: map[int32][2]int64{0: {0, 2}},
+		events:  []kafka.Event{testMessage(0, 0, "a")},
+	}
+	r := &TopicReader{Topic: "test", Deadline: 10 * time.Millisecond, newConsumer: func() (ConsumerInternal, error) { return c, nil }}
+
+	err := r.ReadAll(func(_ []byte, _ time.Time) error { return nil })
+
+	assert.ErrorContains(t, err, "exceeded the deadline")
+	assert.True(t, c.closed)
+}
+
+func TestReadAll_TopicNotFound(t *testing.T) {
+	c := &TestConsumer{metadata: &kafka.Metadata{Topics: map[string]kafka.TopicMetadata{}}}
+	r := &TopicReader{Topic: "test", Deadline: time.Second, newConsumer: func() (ConsumerInternal, error) { return c, nil }}
+
+	err := r.ReadAll(func(_ []byte, _ time.Time) error { return nil })
+
+	assert.Error(t, err)
+}
+
+func TestReadAll_HandlerError(t *testing.T) {
+	c := &TestConsumer{
+		metadata: &kafka.Metadata{Topics: map[string]kafka.TopicMetadata{
+			"test": {Topic: "test", Partitions: []kafka.PartitionMetadata{{ID: 0}}},
+		}},
+		offsets: map[int32][2]int64{0: {0, 1}},
+		events:  []kafka.Event{testMessage(0, 0, "a")},
+	}
+	r := &TopicReader{Topic: "test", Deadline: time.Second, newConsumer: func() (ConsumerInternal, error) { return c, nil }}
+
+	err := r.ReadAll(func(_ []byte, _ time.Time) error { return errors.New("test") })
+
+	assert.EqualError(t, err, "test")
+}
+
+func TestNewTopicReader(t *testing.T) {
+	r := NewTopicReader(config.Kafka{SecurityProtocol: "PLAINTEXT"}, "test")
+
+	c, err := r.newConsumer()
+
+	assert.Equal(t, "test", r.Topic)
+	assert.Equal(t, defaultReadDeadline, r.Deadline)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+}
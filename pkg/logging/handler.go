@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/phsym/console-slog"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"log/slog"
+)
+
+const (
+	FormatConsole = "console"
+	FormatJson    = "json"
+	FormatLogfmt  = "logfmt"
+)
+
+// NewHandler creates a log handler writing records in the given format
+func NewHandler(w io.Writer, format string, level slog.Leveler, color bool) (slog.Handler, error) {
+	switch format {
+	case "", FormatConsole:
+		return console.NewHandler(w, &console.HandlerOptions{Level: level, NoColor: !color}), nil
+	case FormatJson:
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}), nil
+	case FormatLogfmt:
+		return slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", format)
+	}
+}
+
+// NewFileWriter returns a writer to the given log file which is rotated
+// when it reaches maxSize megabytes
+func NewFileWriter(path string, maxSize, maxBackups, maxAge int, compress bool) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		Compress:   compress,
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "json", format: FormatJson, expected: `"msg":"test","foo":"bar"`},
+		{name: "logfmt", format: FormatLogfmt, expected: `msg=test foo=bar`},
+		{name: "console", format: FormatConsole, expected: `test foo=bar`},
+		{name: "default", format: "", expected: `test foo=bar`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h, err := NewHandler(&buf, c.format, slog.LevelInfo, false)
+
+			slog.New(h).Info("test", "foo", "bar")
+
+			assert.NoError(t, err)
+			assert.Contains(t, buf.String(), c.expected)
+		})
+	}
+}
+
+func TestNewHandler_InvalidFormat(t *testing.T) {
+	_, err := NewHandler(&bytes.Buffer{}, "xml", slog.LevelInfo, false)
+
+	assert.EqualError(t, err, "unsupported log format: xml")
+}
+
+func TestNewFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w := NewFileWriter(path, 1, 1, 1, false)
+
+	_, err := w.Write([]byte("test\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	actual, _ := os.ReadFile(path)
+	assert.Equal(t, "test\n", string(actual))
+}
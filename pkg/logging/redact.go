@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const RedactedValue = "***"
+
+// RedactHandler masks the values of all attributes with one of the given keys,
+// including attributes nested in groups
+type RedactHandler struct {
+	handler slog.Handler
+	keys    map[string]struct{}
+}
+
+func NewRedactHandler(h slog.Handler, keys []string) *RedactHandler {
+	m := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		m[strings.ToLower(k)] = struct{}{}
+	}
+	return &RedactHandler{handler: h, keys: m}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(h.keys) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a)
+	}
+	return &RedactHandler{handler: h.handler.WithAttrs(redacted), keys: h.keys}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{handler: h.handler.WithGroup(name), keys: h.keys}
+}
+
+func (h *RedactHandler) redact(a slog.Attr) slog.Attr {
+	if _, ok := h.keys[strings.ToLower(a.Key)]; ok {
+		return slog.String(a.Key, RedactedValue)
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+
+	group := v.Group()
+	attrs := make([]slog.Attr, len(group))
+	for i, g := range group {
+		attrs[i] = h.redact(g)
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+type signer struct {
+	Id string
+}
+
+func (s signer) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", s.Id))
+}
+
+func TestRedactHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactHandler(slog.NewTextHandler(&buf, nil), []string{"Password", "id", "body"}))
+
+	logger.Info("test",
+		"password", "secret",
+		"user", "test",
+		slog.Group("request", slog.String("body", "{}"), slog.Int("length", 2)),
+		"signer", signer{Id: "4711"},
+	)
+
+	assert.Contains(t, buf.String(), "password=*** user=test request.body=*** request.length=2 signer.id=***")
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "4711")
+}
+
+func TestRedactHandler_WithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactHandler(slog.NewTextHandler(&buf, nil), []string{"password"})).
+		With("password", "secret").
+		WithGroup("g")
+
+	logger.Info("test", "password", "secret")
+
+	assert.Contains(t, buf.String(), "password=*** g.password=***")
+}
+
+func TestRedactHandler_NoKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewRedactHandler(slog.NewTextHandler(&buf, nil), nil)
+
+	slog.New(h).Info("test", "password", "secret")
+
+	assert.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.Contains(t, buf.String(), "password=secret")
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	AddPersonType        = "EPIX.AddPerson"
+	UpdatePersonType     = "EPIX.UpdatePerson"
+	DeactivatePersonType = "EPIX.DeactivatePerson"
+	DeletePersonType     = "EPIX.DeletePerson"
+)
+
+var ErrIncompletePerson = errors.New("domainName and mpiId are required")
+
+// EPIX is the source of identity management notifications
+var EPIX = &Source{
+	Name:           "E-PIX",
+	ClientIdPrefix: "E-PIX_",
+	payloads: map[string]func() Payload{
+		AddPersonType:        newPersonPayload,
+		UpdatePersonType:     newPersonPayload,
+		DeactivatePersonType: newPersonPayload,
+		DeletePersonType:     newPersonPayload,
+	},
+}
+
+func newPersonPayload() Payload {
+	return &PersonData{}
+}
+
+// PersonData is the payload of E-PIX person notifications
+type PersonData struct {
+	DomainName  *string         `json:"domainName"`
+	MpiId       *string         `json:"mpiId"`
+	Identifiers []Identifier    `json:"identifiers,omitempty"`
+	Identity    json.RawMessage `json:"identity,omitempty"`
+}
+
+type Identifier struct {
+	IdentifierDomain string `json:"identifierDomain"`
+	Value            string `json:"value"`
+}
+
+func (d *PersonData) Validate() error {
+	if d.DomainName == nil || d.MpiId == nil {
+		return ErrIncompletePerson
+	}
+	return nil
+}
+
+// Key is derived from the domain and the person's MPI id
+func (d *PersonData) Key() string {
+	return hash(*d.DomainName, *d.MpiId)
+}
@@ -0,0 +1,54 @@
+package notification
+
+import "encoding/json"
+
+// FormatHeader is the Kafka record header with the detected gICS notification format
+const FormatHeader = "X-Gics-Format"
+
+// gICS notification formats, by the structure of the notification. The gICS
+// versions sending them are not documented, so no versions are derived.
+const (
+	// FormatString has data as string without type and clientId
+	FormatString = "string"
+	// FormatStringTyped repeats type and clientId in data
+	FormatStringTyped = "string-typed"
+	// FormatStringSignerDetails adds fhirID or creationDate to signer ids
+	FormatStringSignerDetails = "string-signer-details"
+	// FormatObject has data as JSON object
+	FormatObject = "object"
+)
+
+// Format detects the gICS notification format from the structure of the
+// notification. Returns an empty string without data.
+func (n Notification) Format() string {
+	if n.Data == nil {
+		return ""
+	}
+	if n.dataObject {
+		return FormatObject
+	}
+
+	var d struct {
+		Type       *string `json:"type"`
+		ClientId   *string `json:"clientId"`
+		ConsentKey *struct {
+			SignerIds []struct {
+				FhirId       *string `json:"fhirID"`
+				CreationDate *string `json:"creationDate"`
+			} `json:"signerIds"`
+		} `json:"consentKey"`
+	}
+	_ = json.Unmarshal([]byte(*n.Data), &d)
+
+	if d.ConsentKey != nil {
+		for _, s := range d.ConsentKey.SignerIds {
+			if s.FhirId != nil || s.CreationDate != nil {
+				return FormatStringSignerDetails
+			}
+		}
+	}
+	if d.Type != nil || d.ClientId != nil {
+		return FormatStringTyped
+	}
+	return FormatString
+}
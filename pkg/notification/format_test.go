@@ -0,0 +1,45 @@
+package notification
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNotification_Format(t *testing.T) {
+	cases := map[string]string{
+		"withoutData":      `{"clientId":"gICS_Web"}`,
+		"legacy":           `{"data":"{\"consentKey\":{\"signerIds\":[{\"idType\":\"A\",\"id\":\"1\"}]}}"}`,
+		"innerType":        `{"data":"{\"type\":\"GICS.AddConsent\",\"clientId\":\"gICS_Web\",\"consentKey\":{\"signerIds\":[{\"idType\":\"A\",\"id\":\"1\"}]}}"}`,
+		"signerIdDetails":  `{"data":"{\"type\":\"GICS.AddConsent\",\"consentKey\":{\"signerIds\":[{\"fhirID\":\"f\",\"idType\":\"A\",\"id\":\"1\"}]}}"}`,
+		"objectData":       `{"data":{"type":"GICS.AddConsent"}}`,
+		"templateLegacy":   `{"data":"{\"consentTemplateKey\":{}}"}`,
+		"templateWithType": `{"data":"{\"type\":\"GICS.AddConsentTemplate\",\"consentTemplateKey\":{}}"}`,
+	}
+	expected := map[string]string{
+		"withoutData":      "",
+		"legacy":           FormatString,
+		"innerType":        FormatStringTyped,
+		"signerIdDetails":  FormatStringSignerDetails,
+		"objectData":       FormatObject,
+		"templateLegacy":   FormatString,
+		"templateWithType": FormatStringTyped,
+	}
+
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			var n Notification
+			assert.NoError(t, json.Unmarshal([]byte(body), &n))
+
+			assert.Equal(t, expected[name], n.Format())
+		})
+	}
+}
+
+func TestNotification_FormatGolden(t *testing.T) {
+	_, data := readDevPayload(t, "gics-notification.json")
+	s := string(data)
+	n := Notification{Data: &s}
+
+	assert.Equal(t, FormatStringSignerDetails, n.Format())
+}
@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readDevPayload reads the notification data and type of a payload in dev/,
+// which is either notification data, a notification or wrapped in "data"
+func readDevPayload(t *testing.T, name string) (string, []byte) {
+	b, err := os.ReadFile(filepath.Join("..", "..", "dev", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var envelope struct {
+		Type *string         `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(b, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Data == nil {
+		return *envelope.Type, b
+	}
+
+	data := []byte(envelope.Data)
+	var s string
+	if json.Unmarshal(envelope.Data, &s) == nil {
+		data = []byte(s)
+	}
+	if envelope.Type == nil {
+		var d NotificationData
+		_ = json.Unmarshal(data, &d)
+		envelope.Type = d.Type
+	}
+	return *envelope.Type, data
+}
+
+func TestParse_Golden(t *testing.T) {
+	for _, name := range []string{
+		"gics-notification.json",
+		"notification-data-addConsent.json",
+		"notification-data-setQcForConsent.json",
+	} {
+		t.Run(name, func(t *testing.T) {
+			notificationType, data := readDevPayload(t, name)
+
+			p, err := GICS.Parse(notificationType, data)
+			assert.NoError(t, err)
+
+			// key derivation must not change the payload
+			_ = p.Key()
+			actual, _ := json.Marshal(p)
+			assert.JSONEq(t, string(data), string(actual))
+		})
+	}
+}
+
+func TestNotification_RawGolden(t *testing.T) {
+	b, _ := os.ReadFile(filepath.Join("..", "..", "dev", "gics-notification.json"))
+	var n Notification
+	_ = json.Unmarshal(b, &n)
+
+	actual := n.Raw()
+
+	var r struct {
+		ClientId  *string         `json:"clientId"`
+		CreatedAt *string         `json:"createdAt"`
+		Data      json.RawMessage `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(actual, &r))
+	assert.Equal(t, n.ClientId, r.ClientId)
+	assert.Equal(t, n.CreatedAt, r.CreatedAt)
+	// data is embedded byte for byte
+	assert.Equal(t, *n.Data, string(r.Data))
+}
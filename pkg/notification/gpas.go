@@ -0,0 +1,46 @@
+package notification
+
+import "errors"
+
+const (
+	InsertPseudonymType         = "GPAS.InsertValuePseudonymPair"
+	GetOrCreatePseudonymForType = "GPAS.GetOrCreatePseudonymFor"
+	DeletePseudonymType         = "GPAS.DeleteEntry"
+)
+
+var ErrIncompletePseudonym = errors.New("domainName, originalValue and pseudonym are required")
+
+// GPAS is the source of pseudonym notifications
+var GPAS = &Source{
+	Name:           "gPAS",
+	ClientIdPrefix: "gPAS_",
+	payloads: map[string]func() Payload{
+		InsertPseudonymType:         newPseudonymPayload,
+		GetOrCreatePseudonymForType: newPseudonymPayload,
+		DeletePseudonymType:         newPseudonymPayload,
+	},
+}
+
+func newPseudonymPayload() Payload {
+	return &PseudonymData{}
+}
+
+// PseudonymData is the payload of gPAS pseudonym notifications
+type PseudonymData struct {
+	DomainName    *string `json:"domainName"`
+	OriginalValue *string `json:"originalValue"`
+	Pseudonym     *string `json:"pseudonym"`
+}
+
+func (d *PseudonymData) Validate() error {
+	if d.DomainName == nil || d.OriginalValue == nil || d.Pseudonym == nil {
+		return ErrIncompletePseudonym
+	}
+	return nil
+}
+
+// Key is derived from the domain and the original value, so there is one
+// record per pseudonymized value in a compacted topic
+func (d *PseudonymData) Key() string {
+	return hash(*d.DomainName, *d.OriginalValue)
+}
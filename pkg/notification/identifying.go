@@ -0,0 +1,56 @@
+package notification
+
+import "fmt"
+
+// Identifying is implemented by models with fields identifying a person
+type Identifying interface {
+	// IdentifyingFields returns pointers to the identifying values by JSON path
+	IdentifyingFields() map[string]*string
+}
+
+func (d *NotificationData) IdentifyingFields() map[string]*string {
+	fields := map[string]*string{}
+	if d.ConsentKey != nil {
+		addSignerIdFields(fields, "consentKey.signerIds.", d.ConsentKey.SignerIds)
+	}
+	if d.Context != nil {
+		fields["context.qc.inspector"] = &d.Context.Qc.Inspector
+	}
+	return fields
+}
+
+func (s *ConsentSnapshot) IdentifyingFields() map[string]*string {
+	fields := map[string]*string{}
+	if s.ConsentKey != nil {
+		addSignerIdFields(fields, "consentKey.signerIds.", s.ConsentKey.SignerIds)
+	}
+	if s.Qc != nil {
+		fields["qc.inspector"] = &s.Qc.Inspector
+	}
+	return fields
+}
+
+func (d *SignerIdData) IdentifyingFields() map[string]*string {
+	fields := map[string]*string{}
+	addSignerIdFields(fields, "signerIds.", d.SignerIds)
+	if d.AddedSignerId != nil {
+		addSignerIdField(fields, "addedSignerId.", d.AddedSignerId)
+	}
+	return fields
+}
+
+func (d *PseudonymData) IdentifyingFields() map[string]*string {
+	return map[string]*string{"originalValue": d.OriginalValue}
+}
+
+func addSignerIdFields(fields map[string]*string, prefix string, ids []SignerId) {
+	for i := range ids {
+		addSignerIdField(fields, fmt.Sprintf("%s%d.", prefix, i), &ids[i])
+	}
+}
+
+func addSignerIdField(fields map[string]*string, prefix string, id *SignerId) {
+	fields[prefix+"id"] = &id.Id
+	fields[prefix+"name"] = id.Name
+	fields[prefix+"fhirID"] = id.FhirId
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIdentifyingFields(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(`{"consentKey":{"signerIds":[{"idType":"A","id":"1","name":"Max","orderNumber":1},{"idType":"B","id":"2","orderNumber":2}]},"context":{"qc":{"inspector":"admin"}}}`), &d)
+
+	actual := d.IdentifyingFields()
+
+	assert.Equal(t, []string{
+		"consentKey.signerIds.0.fhirID", "consentKey.signerIds.0.id", "consentKey.signerIds.0.name",
+		"consentKey.signerIds.1.fhirID", "consentKey.signerIds.1.id", "consentKey.signerIds.1.name",
+		"context.qc.inspector",
+	}, slices.Sorted(maps.Keys(actual)))
+	assert.Equal(t, "1", *actual["consentKey.signerIds.0.id"])
+	assert.Equal(t, "Max", *actual["consentKey.signerIds.0.name"])
+	assert.Nil(t, actual["consentKey.signerIds.1.name"])
+	assert.Equal(t, "admin", *actual["context.qc.inspector"])
+
+	// fields point into the value
+	*actual["consentKey.signerIds.1.id"] = "x"
+	assert.Equal(t, "x", d.ConsentKey.SignerIds[1].Id)
+}
+
+func TestIdentifyingFields_Snapshot(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(`{"consentKey":{"signerIds":[{"idType":"A","id":"1"}]},"context":{"qc":{"inspector":"admin"}}}`), &d)
+	s := NewSnapshot(SetQcForConsentType, d, time.Now())
+
+	actual := s.IdentifyingFields()
+
+	assert.Equal(t, "1", *actual["consentKey.signerIds.0.id"])
+	assert.Equal(t, "admin", *actual["qc.inspector"])
+}
+
+func TestIdentifyingFields_PseudonymData(t *testing.T) {
+	var d PseudonymData
+	_ = json.Unmarshal([]byte(`{"domainName":"MII","originalValue":"1001","pseudonym":"psn"}`), &d)
+
+	actual := d.IdentifyingFields()
+
+	assert.Equal(t, map[string]*string{"originalValue": d.OriginalValue}, actual)
+}
+
+func TestIdentifyingFields_SignerIdData(t *testing.T) {
+	var d SignerIdData
+	_ = json.Unmarshal([]byte(`{"signerIds":[{"idType":"A","id":"1"}],"addedSignerId":{"idType":"B","id":"2"}}`), &d)
+
+	actual := d.IdentifyingFields()
+
+	assert.Equal(t, "1", *actual["signerIds.0.id"])
+	assert.Equal(t, "2", *actual["addedSignerId.id"])
+}
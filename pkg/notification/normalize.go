@@ -0,0 +1,130 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// RawKey is the output section with the original values of normalized fields
+const RawKey = "raw"
+
+// Dated is implemented by models with date fields
+type Dated interface {
+	// DateFields returns pointers to the date values by JSON path
+	DateFields() map[string]*string
+}
+
+// Raw contains the original values of normalized fields by JSON path
+type Raw map[string]string
+
+func (d *NotificationData) DateFields() map[string]*string {
+	if d.ConsentKey == nil {
+		return nil
+	}
+	return d.ConsentKey.dateFields("consentKey.")
+}
+
+func (k *ConsentKey) dateFields(prefix string) map[string]*string {
+	fields := map[string]*string{prefix + "consentDate": k.ConsentDate}
+	for i := range k.SignerIds {
+		fields[fmt.Sprintf("%ssignerIds.%d.creationDate", prefix, i)] = k.SignerIds[i].CreationDate
+	}
+	return fields
+}
+
+func (s *ConsentSnapshot) DateFields() map[string]*string {
+	if s.ConsentKey == nil {
+		return nil
+	}
+	return s.ConsentKey.dateFields("consentKey.")
+}
+
+// Transform modifies the copy of a value before it is marshalled and may
+// return a section to add to the output
+type Transform func(c any) (key string, section any, err error)
+
+// MarshalCopy marshals a copy of v with the transforms applied, so v is kept
+// unchanged. Only pointers are copied, other values are marshalled unchanged.
+func MarshalCopy(v any, transforms ...Transform) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(transforms) == 0 || reflect.TypeOf(v).Kind() != reflect.Pointer {
+		return b, err
+	}
+
+	c := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+
+	sections := map[string]any{}
+	for _, t := range transforms {
+		key, section, err := t(c)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			sections[key] = section
+		}
+	}
+
+	b, err = json.Marshal(c)
+	if err != nil || len(sections) == 0 {
+		return b, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	for key, section := range sections {
+		if fields[key], err = json.Marshal(section); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// NormalizeDates marshals a copy of v with all date fields converted by format
+// and adds their original values in the raw section. Values without date
+// fields are marshalled unchanged.
+func NormalizeDates(v any, format func(string) (string, error)) ([]byte, error) {
+	return MarshalCopy(v, DateTransform(format))
+}
+
+// DateTransform converts all date fields by format and returns their original
+// values as raw section
+func DateTransform(format func(string) (string, error)) Transform {
+	return func(c any) (string, any, error) {
+		d, ok := c.(Dated)
+		if !ok {
+			return "", nil, nil
+		}
+
+		raw := Raw{}
+		for path, value := range d.DateFields() {
+			if value == nil {
+				continue
+			}
+			normalized, err := format(*value)
+			if err != nil {
+				return "", nil, err
+			}
+			raw[path] = *value
+			*value = normalized
+		}
+		if len(raw) == 0 {
+			return "", nil, nil
+		}
+		return RawKey, raw, nil
+	}
+}
+
+// RestoreDates sets the date fields of d to their original values
+func RestoreDates(d Dated, raw Raw) {
+	for path, value := range d.DateFields() {
+		if original, ok := raw[path]; ok && value != nil {
+			*value = original
+		}
+	}
+}
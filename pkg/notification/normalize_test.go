@@ -0,0 +1,91 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const consentData = `{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"A","id":"1","orderNumber":1}],"consentDate":"2023-05-02 01:57:27"}}`
+
+func toRfc3339(v string) (string, error) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", v, loc)
+	return t.Format(time.RFC3339), err
+}
+
+func TestNormalizeDates(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(consentData), &d)
+
+	actual, err := NormalizeDates(&d, toRfc3339)
+
+	assert.NoError(t, err)
+	var r struct {
+		NotificationData
+		Raw Raw `json:"raw"`
+	}
+	_ = json.Unmarshal(actual, &r)
+	assert.Equal(t, "2023-05-02T01:57:27+02:00", *r.ConsentKey.ConsentDate)
+	assert.Equal(t, Raw{"consentKey.consentDate": "2023-05-02 01:57:27"}, r.Raw)
+	// original is unchanged
+	assert.Equal(t, "2023-05-02 01:57:27", *d.ConsentKey.ConsentDate)
+
+	RestoreDates(&r.NotificationData, r.Raw)
+	assert.Equal(t, "2023-05-02 01:57:27", *r.ConsentKey.ConsentDate)
+}
+
+func TestNormalizeDates_Snapshot(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(consentData), &d)
+	s := NewSnapshot(AddConsentType, d, time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC))
+
+	actual, err := NormalizeDates(&s, toRfc3339)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(actual), `"consentDate":"2023-05-02T01:57:27+02:00"`)
+	assert.Contains(t, string(actual), `"raw":{"consentKey.consentDate":"2023-05-02 01:57:27"}`)
+}
+
+func TestNormalizeDates_WithoutDates(t *testing.T) {
+	d := &TemplateData{}
+	_ = json.Unmarshal([]byte(`{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"}}`), d)
+
+	actual, err := NormalizeDates(d, toRfc3339)
+
+	assert.NoError(t, err)
+	expected, _ := json.Marshal(d)
+	assert.Equal(t, expected, actual)
+}
+
+func TestNormalizeDates_Error(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(consentData), &d)
+
+	_, err := NormalizeDates(&d, func(string) (string, error) { return "", errors.New("invalid date") })
+
+	assert.Error(t, err)
+}
+
+func TestMarshalCopy_Sections(t *testing.T) {
+	var d NotificationData
+	_ = json.Unmarshal([]byte(consentData), &d)
+	upper := func(c any) (string, any, error) {
+		c.(*NotificationData).ConsentKey.SignerIds[0].IdType = "B"
+		return "", nil, nil
+	}
+	section := func(any) (string, any, error) {
+		return "extra", map[string]int{"count": 1}, nil
+	}
+
+	actual, err := MarshalCopy(&d, DateTransform(toRfc3339), upper, section)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(actual), `"idType":"B"`)
+	assert.Contains(t, string(actual), `"extra":{"count":1}`)
+	assert.Contains(t, string(actual), `"raw":{"consentKey.consentDate":"2023-05-02 01:57:27"}`)
+	// original is unchanged
+	assert.Equal(t, "A", d.ConsentKey.SignerIds[0].IdType)
+}
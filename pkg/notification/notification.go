@@ -0,0 +1,161 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+type Notification struct {
+	ClientId  *string `bson:"clientId" json:"clientId"`
+	Type      *string `bson:"type" json:"type"`
+	CreatedAt *string `bson:"createdAt" json:"createdAt"`
+	Data      *string `bson:"data" json:"data"`
+
+	// data was sent as JSON object instead of string
+	dataObject bool
+}
+
+var ErrInvalidData = errors.New("data must be a JSON object or string")
+
+// UnmarshalJSON accepts data as stringified JSON or as embedded JSON object,
+// which is kept as is
+func (n *Notification) UnmarshalJSON(b []byte) error {
+	var v struct {
+		ClientId  *string         `json:"clientId"`
+		Type      *string         `json:"type"`
+		CreatedAt *string         `json:"createdAt"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*n = Notification{ClientId: v.ClientId, Type: v.Type, CreatedAt: v.CreatedAt}
+	data := bytes.TrimSpace(v.Data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+	case data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		n.Data = &s
+	case data[0] == '{':
+		s := string(data)
+		n.Data = &s
+		n.dataObject = true
+	default:
+		return ErrInvalidData
+	}
+	return nil
+}
+
+// Raw returns the notification with the original data JSON embedded as is
+func (n Notification) Raw() []byte {
+	envelope, _ := json.Marshal(struct {
+		ClientId  *string `json:"clientId"`
+		Type      *string `json:"type"`
+		CreatedAt *string `json:"createdAt"`
+	}{n.ClientId, n.Type, n.CreatedAt})
+
+	b := append(envelope[:len(envelope)-1], `,"data":`...)
+	if n.Data == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, *n.Data...)
+	}
+	return append(b, '}')
+}
+
+type PolicyState struct {
+	Key   *PolicyStateKey `bson:"key" json:"key"`
+	Value bool            `bson:"value" json:"value"`
+}
+
+type PolicyStateKey struct {
+	DomainName *string `bson:"domainName" json:"domainName"`
+	Name       *string `bson:"name" json:"name"`
+	Version    *string `bson:"version" json:"version"`
+}
+
+type Context struct {
+	Qc Qc `bson:"qc" json:"qc"`
+}
+
+type Qc struct {
+	QcPassed  bool   `bson:"qcPassed" json:"qcPassed"`
+	Type      string `bson:"type" json:"type"`
+	Inspector string `bson:"inspector" json:"inspector"`
+	// nil if not sent, so an empty comment is kept
+	Comment *string `bson:"comment" json:"comment,omitempty"`
+}
+
+type NotificationData struct {
+	Type                 *string       `bson:"type" json:"type,omitempty"`
+	ClientId             *string       `bson:"clientId" json:"clientId,omitempty"`
+	Context              *Context      `bson:"context" json:"context"`
+	ConsentKey           *ConsentKey   `bson:"consentKey" json:"consentKey"`
+	PreviousPolicyStates []PolicyState `bson:"previousPolicyStates" json:"previousPolicyStates"`
+	CurrentPolicyStates  []PolicyState `bson:"currentPolicyStates" json:"currentPolicyStates"`
+	// Template is added by enrichment and not part of gICS notifications
+	Template *TemplateMetadata `bson:"template" json:"template,omitempty"`
+}
+type ConsentKey struct {
+	ConsentTemplateKey *ConsentTemplateKey `bson:"consentTemplateKey" json:"consentTemplateKey"`
+	SignerIds          []SignerId          `bson:"signerIds" json:"signerIds"`
+	ConsentDate        *string             `bson:"consentDate" json:"consentDate"`
+}
+
+type ConsentTemplateKey struct {
+	DomainName *string `bson:"domainName" json:"domainName"`
+	Name       *string `bson:"name" json:"name"`
+	Version    *string `bson:"version" json:"version"`
+}
+
+type SignerId struct {
+	FhirId       *string `bson:"fhirID" json:"fhirID,omitempty"`
+	IdType       string  `bson:"idType" json:"idType"`
+	Id           string  `bson:"id" json:"id"`
+	Name         *string `bson:"name" json:"name,omitempty"`
+	CreationDate *string `bson:"creationDate" json:"creationDate,omitempty"`
+	OrderNumber  int     `bson:"orderNumber" json:"orderNumber"`
+}
+
+func (d NotificationData) SignerId() *SignerId {
+	if d.ConsentKey == nil || len(d.ConsentKey.SignerIds) == 0 {
+		return nil
+	}
+
+	// sort a copy to keep the original order
+	ids := append([]SignerId(nil), d.ConsentKey.SignerIds...)
+	sort.SliceStable(ids, func(i, j int) bool {
+		return ids[i].OrderNumber < ids[j].OrderNumber
+	})
+
+	return &ids[0]
+}
+
+// ConsentSnapshot is the current state of a consent
+type ConsentSnapshot struct {
+	ConsentKey          *ConsentKey   `json:"consentKey"`
+	CurrentPolicyStates []PolicyState `json:"currentPolicyStates"`
+	Qc                  *Qc           `json:"qc,omitempty"`
+	NotificationType    string        `json:"notificationType"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
+}
+
+func NewSnapshot(notificationType string, d NotificationData, updatedAt time.Time) ConsentSnapshot {
+	s := ConsentSnapshot{
+		ConsentKey:          d.ConsentKey,
+		CurrentPolicyStates: d.CurrentPolicyStates,
+		NotificationType:    notificationType,
+		UpdatedAt:           updatedAt,
+	}
+	if d.Context != nil {
+		s.Qc = &d.Context.Qc
+	}
+	return s
+}
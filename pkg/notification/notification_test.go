@@ -0,0 +1,91 @@
+package notification
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSignerId(t *testing.T) {
+	expected := SignerId{
+		IdType:      "Test-ID",
+		Id:          "1",
+		OrderNumber: 1,
+	}
+
+	d := NotificationData{
+		ConsentKey: &ConsentKey{
+			SignerIds: []SignerId{
+				{
+					IdType:      "Test-ID",
+					Id:          "3",
+					OrderNumber: 3,
+				},
+				expected,
+			},
+		},
+	}
+
+	actual := *d.SignerId()
+
+	assert.Equal(t, expected, actual)
+	// original order is kept
+	assert.Equal(t, "3", d.ConsentKey.SignerIds[0].Id)
+}
+
+func TestNotification_Raw(t *testing.T) {
+	clientId, notificationType, createdAt, data := "gICS_Web", "GICS.AddConsent", "2023-06-05T12:09:10", `{ "b": 1, "a": "<2>" }`
+
+	n := Notification{ClientId: &clientId, Type: &notificationType, CreatedAt: &createdAt, Data: &data}
+
+	assert.Equal(t, `{"clientId":"gICS_Web","type":"GICS.AddConsent","createdAt":"2023-06-05T12:09:10","data":{ "b": 1, "a": "<2>" }}`, string(n.Raw()))
+	assert.Equal(t, `{"clientId":null,"type":null,"createdAt":null,"data":null}`, string(Notification{}.Raw()))
+}
+
+func TestNotification_UnmarshalJSON(t *testing.T) {
+	cases := map[string]*string{
+		`{"data":"{\"a\":1}"}`:    ptrTo(`{"a":1}`),
+		`{"data":{ "a": 1 }}`:     ptrTo(`{ "a": 1 }`),
+		`{"data":null}`:           nil,
+		`{"clientId":"gICS_Web"}`: nil,
+	}
+
+	for body, expected := range cases {
+		t.Run(body, func(t *testing.T) {
+			var n Notification
+
+			err := json.Unmarshal([]byte(body), &n)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, n.Data)
+		})
+	}
+}
+
+func TestNotification_UnmarshalJSONInvalid(t *testing.T) {
+	var n Notification
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"data":42}`), &n), ErrInvalidData)
+	assert.Error(t, json.Unmarshal([]byte(`{"clientId":42}`), &n))
+}
+
+func ptrTo(s string) *string {
+	return &s
+}
+
+func TestNotificationData_RoundTripComment(t *testing.T) {
+	for _, qc := range []string{
+		`{"qcPassed":true,"type":"validated","inspector":"test","comment":""}`,
+		`{"qcPassed":true,"type":"validated","inspector":"test","comment":"checked"}`,
+		`{"qcPassed":true,"type":"validated","inspector":"test"}`,
+	} {
+		data := `{"context":{"qc":` + qc + `},"consentKey":null,"previousPolicyStates":null,"currentPolicyStates":null}`
+
+		var d NotificationData
+		assert.NoError(t, json.Unmarshal([]byte(data), &d))
+		actual, err := json.Marshal(d)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, data, string(actual))
+	}
+}
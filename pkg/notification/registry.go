@@ -0,0 +1,130 @@
+package notification
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	AddConsentType              = "GICS.AddConsent"
+	SetQcForConsentType         = "GICS.SetQcForConsent"
+	UpdateConsentInUseType      = "GICS.UpdateConsentInUse"
+	DeleteConsentType           = "GICS.DeleteConsent"
+	AddSignerIdType             = "GICS.AddSignerIdToSignerId"
+	AddConsentTemplateType      = "GICS.AddConsentTemplate"
+	UpdateConsentTemplateType   = "GICS.UpdateConsentTemplate"
+	DeleteConsentTemplateType   = "GICS.DeleteConsentTemplate"
+	FinaliseConsentTemplateType = "GICS.FinaliseConsentTemplate"
+	// ConsentExpiredType is emitted by this service when a consent's validity has passed
+	ConsentExpiredType = "GICS.ConsentExpired"
+)
+
+var (
+	ErrUnknownType        = errors.New("unknown notification type")
+	ErrMissingSignerId    = errors.New("consentKey contains no signerIds")
+	ErrIncompleteKey      = errors.New("incomplete consent key")
+	ErrIncompleteTemplate = errors.New("incomplete consent template key")
+	ErrIncompleteSigner   = errors.New("incomplete signer id")
+)
+
+// Payload is the typed content of a notification's data
+type Payload interface {
+	// Validate checks if all properties required for the notification type are present
+	Validate() error
+	// Key derives the Kafka record key
+	Key() string
+}
+
+// GICS is the source of consent notifications
+var GICS = &Source{
+	Name:           "gICS",
+	ClientIdPrefix: "gICS_",
+	payloads: map[string]func() Payload{
+		AddConsentType:              newConsentPayload,
+		SetQcForConsentType:         newConsentPayload,
+		UpdateConsentInUseType:      newConsentPayload,
+		DeleteConsentType:           newConsentPayload,
+		AddSignerIdType:             func() Payload { return &SignerIdData{} },
+		AddConsentTemplateType:      newTemplatePayload,
+		UpdateConsentTemplateType:   newTemplatePayload,
+		DeleteConsentTemplateType:   newTemplatePayload,
+		FinaliseConsentTemplateType: newTemplatePayload,
+	},
+}
+
+func newConsentPayload() Payload {
+	return &NotificationData{}
+}
+
+func newTemplatePayload() Payload {
+	return &TemplateData{}
+}
+
+func (d *NotificationData) Validate() error {
+	k := d.ConsentKey
+	if k == nil || k.ConsentDate == nil || !k.ConsentTemplateKey.isComplete() {
+		return ErrIncompleteKey
+	}
+	if len(k.SignerIds) == 0 {
+		return ErrMissingSignerId
+	}
+	return nil
+}
+
+// Key is derived from the consent template, the first signer id and the consent date
+func (d *NotificationData) Key() string {
+	t := d.ConsentKey.ConsentTemplateKey
+	s := d.SignerId()
+	return hash(*t.DomainName, *t.Name, *t.Version, s.IdType, s.Id, *d.ConsentKey.ConsentDate)
+}
+
+// SignerIdData is the payload of signer id additions
+type SignerIdData struct {
+	SignerIds     []SignerId `json:"signerIds"`
+	AddedSignerId *SignerId  `json:"addedSignerId"`
+}
+
+func (d *SignerIdData) Validate() error {
+	if len(d.SignerIds) == 0 || d.AddedSignerId == nil {
+		return ErrIncompleteSigner
+	}
+	return nil
+}
+
+// Key is derived from the signer's first existing signer id
+func (d *SignerIdData) Key() string {
+	s := NotificationData{ConsentKey: &ConsentKey{SignerIds: d.SignerIds}}.SignerId()
+	return hash(s.IdType, s.Id)
+}
+
+// TemplateData is the payload of consent template changes
+type TemplateData struct {
+	ConsentTemplateKey *ConsentTemplateKey `json:"consentTemplateKey"`
+}
+
+func (d *TemplateData) Validate() error {
+	if !d.ConsentTemplateKey.isComplete() {
+		return ErrIncompleteTemplate
+	}
+	return nil
+}
+
+// Key is derived from the consent template
+func (d *TemplateData) Key() string {
+	t := d.ConsentTemplateKey
+	return hash(*t.DomainName, *t.Name, *t.Version)
+}
+
+func (t *ConsentTemplateKey) isComplete() bool {
+	return t != nil && t.DomainName != nil && t.Name != nil && t.Version != nil
+}
+
+func hash(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		h.Write([]byte(v))
+	}
+	sum := h.Sum(nil)
+	return fmt.Sprintf("%x", sum)
+}
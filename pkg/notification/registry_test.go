@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type ParseTestCase struct {
+	name             string
+	notificationType string
+	data             string
+	expectedType     Payload
+	expectedKey      string
+	expectedErr      error
+}
+
+func sha(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
+
+func TestParse(t *testing.T) {
+	consent := `{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"B","id":"2","orderNumber":2},{"idType":"A","id":"1","orderNumber":1}],"consentDate":"2023-05-02 01:57:27"}}`
+
+	cases := []ParseTestCase{
+		{name: "addConsent", notificationType: AddConsentType, data: consent,
+			expectedType: &NotificationData{}, expectedKey: sha("MIIPatienteneinwilligung MII1.6.dA12023-05-02 01:57:27")},
+		{name: "updateConsentInUse", notificationType: UpdateConsentInUseType, data: consent,
+			expectedType: &NotificationData{}, expectedKey: sha("MIIPatienteneinwilligung MII1.6.dA12023-05-02 01:57:27")},
+		{name: "missingSignerId", notificationType: SetQcForConsentType,
+			data:        `{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[],"consentDate":"2023-05-02 01:57:27"}}`,
+			expectedErr: ErrMissingSignerId},
+		{name: "incompleteConsentKey", notificationType: DeleteConsentType, data: `{"consentKey":{}}`,
+			expectedErr: ErrIncompleteKey},
+		{name: "addSignerId", notificationType: AddSignerIdType,
+			data:         `{"signerIds":[{"idType":"A","id":"1","orderNumber":1}],"addedSignerId":{"idType":"B","id":"2"}}`,
+			expectedType: &SignerIdData{}, expectedKey: sha("A1")},
+		{name: "incompleteSignerId", notificationType: AddSignerIdType, data: `{"signerIds":[]}`,
+			expectedErr: ErrIncompleteSigner},
+		{name: "templateChange", notificationType: UpdateConsentTemplateType,
+			data:         `{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"}}`,
+			expectedType: &TemplateData{}, expectedKey: sha("MIIPatienteneinwilligung MII1.6.d")},
+		{name: "incompleteTemplate", notificationType: DeleteConsentTemplateType, data: `{"consentTemplateKey":{"name":"test"}}`,
+			expectedErr: ErrIncompleteTemplate},
+		{name: "unknownType", notificationType: "GICS.Foo", data: `{}`, expectedErr: ErrUnknownType},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := GICS.Parse(c.notificationType, []byte(c.data))
+
+			if c.expectedErr != nil {
+				assert.ErrorIs(t, err, c.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, c.expectedType, actual)
+			assert.Equal(t, c.expectedKey, actual.Key())
+		})
+	}
+}
+
+func TestParse_InvalidJson(t *testing.T) {
+	_, err := GICS.Parse(AddConsentType, []byte("test"))
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Source is a THS service sending notifications, identified by its client id prefix
+type Source struct {
+	Name           string
+	ClientIdPrefix string
+	payloads       map[string]func() Payload
+}
+
+// Sources are all supported notification sources
+var Sources = []*Source{GICS, EPIX, GPAS}
+
+// SourceOf returns the source with the client id's prefix or nil, if none matches
+func SourceOf(clientId string) *Source {
+	for _, s := range Sources {
+		if strings.HasPrefix(clientId, s.ClientIdPrefix) {
+			return s
+		}
+	}
+	return nil
+}
+
+// SourceByName returns the source with the (case-insensitive) name or nil, if none matches
+func SourceByName(name string) *Source {
+	for _, s := range Sources {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Register adds or replaces the payload model of a notification type
+func (s *Source) Register(notificationType string, newPayload func() Payload) {
+	s.payloads[notificationType] = newPayload
+}
+
+func (s *Source) IsKnownType(notificationType string) bool {
+	_, ok := s.payloads[notificationType]
+	return ok
+}
+
+// Parse unmarshals the notification data to the payload model registered
+// for the notification type and validates it
+func (s *Source) Parse(notificationType string, data []byte) (Payload, error) {
+	newPayload, ok := s.payloads[notificationType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, notificationType)
+	}
+
+	p := newPayload()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, p.Validate()
+}
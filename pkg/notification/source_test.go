@@ -0,0 +1,69 @@
+package notification
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSourceOf(t *testing.T) {
+	cases := map[string]*Source{
+		"gICS_Web":     GICS,
+		"E-PIX_Web":    EPIX,
+		"gPAS_Web":     GPAS,
+		"not_gICS_Web": nil,
+		"gics_Web":     nil,
+		"":             nil,
+		"E-PIX":        nil,
+	}
+
+	for clientId, expected := range cases {
+		t.Run(clientId, func(t *testing.T) {
+			assert.Equal(t, expected, SourceOf(clientId))
+		})
+	}
+}
+
+func TestSource_Parse(t *testing.T) {
+	cases := map[*Source][]ParseTestCase{
+		EPIX: {
+			{name: "person", notificationType: AddPersonType,
+				data:         `{"domainName":"MII","mpiId":"1001","identifiers":[{"identifierDomain":"KIS","value":"42"}]}`,
+				expectedType: &PersonData{}, expectedKey: sha("MII1001")},
+			{name: "incompletePerson", notificationType: DeletePersonType, data: `{"domainName":"MII"}`,
+				expectedErr: ErrIncompletePerson},
+			{name: "otherSourceType", notificationType: AddConsentType, data: `{}`, expectedErr: ErrUnknownType},
+		},
+		GPAS: {
+			{name: "pseudonym", notificationType: GetOrCreatePseudonymForType,
+				data:         `{"domainName":"MII","originalValue":"1001","pseudonym":"psn-1"}`,
+				expectedType: &PseudonymData{}, expectedKey: sha("MII1001")},
+			{name: "incompletePseudonym", notificationType: DeletePseudonymType, data: `{"domainName":"MII","originalValue":"1001"}`,
+				expectedErr: ErrIncompletePseudonym},
+		},
+	}
+
+	for src, sourceCases := range cases {
+		for _, c := range sourceCases {
+			t.Run(src.Name+"/"+c.name, func(t *testing.T) {
+				actual, err := src.Parse(c.notificationType, []byte(c.data))
+
+				if c.expectedErr != nil {
+					assert.ErrorIs(t, err, c.expectedErr)
+					return
+				}
+				assert.NoError(t, err)
+				assert.IsType(t, c.expectedType, actual)
+				assert.Equal(t, c.expectedKey, actual.Key())
+			})
+		}
+	}
+}
+
+func TestSource_Register(t *testing.T) {
+	assert.False(t, GICS.IsKnownType("GICS.Test"))
+
+	GICS.Register("GICS.Test", newTemplatePayload)
+	t.Cleanup(func() { delete(GICS.payloads, "GICS.Test") })
+
+	assert.True(t, GICS.IsKnownType("GICS.Test"))
+}
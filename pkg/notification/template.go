@@ -0,0 +1,23 @@
+package notification
+
+// TemplateMetadata describes a consent template as resolved from gICS
+type TemplateMetadata struct {
+	Title      string              `json:"title,omitempty"`
+	Type       string              `json:"type,omitempty"`
+	Modules    []TemplateModule    `json:"modules,omitempty"`
+	Expiration *TemplateExpiration `json:"expiration,omitempty"`
+}
+
+// TemplateModule is a module of a consent template with its policies
+type TemplateModule struct {
+	Key      PolicyStateKey   `json:"key"`
+	Title    string           `json:"title,omitempty"`
+	Policies []PolicyStateKey `json:"policies,omitempty"`
+}
+
+// TemplateExpiration is the expiration rule of consents for a template,
+// either a fixed date or an ISO 8601 period after the consent date
+type TemplateExpiration struct {
+	FixedDate string `json:"fixedDate,omitempty"`
+	Period    string `json:"period,omitempty"`
+}
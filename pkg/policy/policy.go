@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"time"
+)
+
+const (
+	Permit  Decision = "permit"
+	Deny    Decision = "deny"
+	Unknown Decision = "unknown"
+
+	dateLayout        = "2006-01-02"
+	dateTimeLayout    = "2006-01-02T15:04:05"
+	consentDateLayout = "2006-01-02 15:04:05"
+)
+
+type Decision string
+
+// Policy identifies a policy by name and optionally domain and version
+type Policy struct {
+	DomainName string `json:"domainName,omitempty"`
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+}
+
+type DecidingConsent struct {
+	ConsentTemplateKey notification.ConsentTemplateKey `json:"consentTemplateKey"`
+	ConsentDate        string                          `json:"consentDate"`
+}
+
+type Result struct {
+	Policy   Policy           `json:"policy"`
+	Decision Decision         `json:"decision"`
+	Consent  *DecidingConsent `json:"consent,omitempty"`
+}
+
+func (p Policy) matches(k *notification.PolicyStateKey) bool {
+	if k == nil || k.Name == nil || *k.Name != p.Name {
+		return false
+	}
+	if p.DomainName != "" && (k.DomainName == nil || *k.DomainName != p.DomainName) {
+		return false
+	}
+	if p.Version != "" && (k.Version == nil || *k.Version != p.Version) {
+		return false
+	}
+	return true
+}
+
+// ParseReferenceDate parses a date (which refers to the end of that day) or a
+// local date time in the zone of now. Defaults to now, which has to be in the
+// zone of the consent dates.
+func ParseReferenceDate(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now.Truncate(time.Second), nil
+	}
+	if d, err := time.ParseInLocation(dateLayout, s, now.Location()); err == nil {
+		return time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 0, d.Location()), nil
+	}
+	if d, err := time.ParseInLocation(dateTimeLayout, s, now.Location()); err == nil {
+		return d, nil
+	}
+	return time.Time{}, errors.New("invalid reference date, expected format: " + dateLayout + " or " + dateTimeLayout)
+}
+
+// FormatDate formats a reference date like the consent dates
+func FormatDate(t time.Time) string {
+	return t.Format(consentDateLayout)
+}
+
+// Check decides each policy by the most recent consent given until the
+// reference date which contains the policy. A policy is permitted, if any of
+// the matching policy states of the deciding consent is granted. QC states and
+// policy validities are not considered. As the store only keeps the latest
+// consent per signer and template, a consent replaced after the reference date
+// is missing and past dates may be decided by an older consent or unknown.
+// Consents with a date that can't be parsed are skipped.
+func Check(consents []store.Consent, policies []Policy, referenceDate time.Time, parse func(string) (time.Time, error)) []Result {
+	dates := make([]*time.Time, len(consents))
+	for i, c := range consents {
+		if d, err := parse(c.ConsentDate); err == nil {
+			dates[i] = &d
+		}
+	}
+
+	results := make([]Result, len(policies))
+	for i, p := range policies {
+		results[i] = Result{Policy: p, Decision: Unknown}
+
+		var deciding *store.Consent
+		var decidingDate time.Time
+		var decision Decision
+		for j := range consents {
+			c, date := &consents[j], dates[j]
+			if date == nil || date.After(referenceDate) || (deciding != nil && !date.After(decidingDate)) {
+				continue
+			}
+
+			if d, ok := decide(c.CurrentPolicyStates, p); ok {
+				deciding = c
+				decidingDate = *date
+				decision = d
+			}
+		}
+
+		if deciding != nil {
+			results[i].Decision = decision
+			results[i].Consent = &DecidingConsent{
+				ConsentTemplateKey: deciding.ConsentTemplateKey,
+				ConsentDate:        deciding.ConsentDate,
+			}
+		}
+	}
+	return results
+}
+
+func decide(states []notification.PolicyState, p Policy) (Decision, bool) {
+	found := false
+	for _, s := range states {
+		if p.matches(s.Key) {
+			if s.Value {
+				return Permit, true
+			}
+			found = true
+		}
+	}
+	if found {
+		return Deny, true
+	}
+	return "", false
+}
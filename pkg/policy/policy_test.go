@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+func consent(version, consentDate string, states map[string]bool) store.Consent {
+	c := store.Consent{
+		ConsentTemplateKey: notification.ConsentTemplateKey{DomainName: ptr("MII"), Name: ptr("Patienteneinwilligung MII"), Version: ptr(version)},
+		ConsentDate:        consentDate,
+	}
+	for name, value := range states {
+		c.CurrentPolicyStates = append(c.CurrentPolicyStates, notification.PolicyState{
+			Key:   &notification.PolicyStateKey{DomainName: ptr("MII"), Name: ptr(name), Version: ptr("1.0")},
+			Value: value,
+		})
+	}
+	return c
+}
+
+type CheckTestCase struct {
+	name            string
+	policy          Policy
+	referenceDate   string
+	expected        Decision
+	expectedVersion string
+}
+
+func TestCheck(t *testing.T) {
+	consents := []store.Consent{
+		consent("1.6.d", "2023-05-02 01:57:27", map[string]bool{"MDAT_erheben": true, "IDAT_erheben": true}),
+		consent("1.7.2", "2024-01-10 12:00:00", map[string]bool{"MDAT_erheben": false}),
+	}
+
+	cases := []CheckTestCase{
+		{name: "latestConsentDecides", policy: Policy{Name: "MDAT_erheben"}, referenceDate: "2024-02-01 00:00:00",
+			expected: Deny, expectedVersion: "1.7.2"},
+		{name: "referenceDateBeforeLatest", policy: Policy{Name: "MDAT_erheben"}, referenceDate: "2023-12-31 23:59:59",
+			expected: Permit, expectedVersion: "1.6.d"},
+		{name: "policyOnlyInOlderConsent", policy: Policy{DomainName: "MII", Name: "IDAT_erheben", Version: "1.0"},
+			referenceDate: "2024-02-01 00:00:00", expected: Permit, expectedVersion: "1.6.d"},
+		{name: "versionMismatch", policy: Policy{Name: "IDAT_erheben", Version: "2.0"},
+			referenceDate: "2024-02-01 00:00:00", expected: Unknown},
+		{name: "domainMismatch", policy: Policy{DomainName: "Other", Name: "IDAT_erheben"},
+			referenceDate: "2024-02-01 00:00:00", expected: Unknown},
+		{name: "beforeAnyConsent", policy: Policy{Name: "MDAT_erheben"}, referenceDate: "2020-01-01 00:00:00",
+			expected: Unknown},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			referenceDate, _ := timestamp.Default.Parse(c.referenceDate)
+			actual := Check(consents, []Policy{c.policy}, referenceDate, timestamp.Default.Parse)
+
+			assert.Len(t, actual, 1)
+			assert.Equal(t, c.policy, actual[0].Policy)
+			assert.Equal(t, c.expected, actual[0].Decision)
+			if c.expectedVersion != "" {
+				assert.Equal(t, c.expectedVersion, *actual[0].Consent.ConsentTemplateKey.Version)
+			} else {
+				assert.Nil(t, actual[0].Consent)
+			}
+		})
+	}
+}
+
+func TestCheck_ComparesDatesByTime(t *testing.T) {
+	consents := []store.Consent{
+		consent("1.6.d", "2024-01-10T12:00:00+01:00", map[string]bool{"MDAT_erheben": true}),
+		// more recent, although ordered before as string
+		consent("1.7.2", "2024-01-10 12:00:00.5", map[string]bool{"MDAT_erheben": false}),
+		consent("1.8", "invalid", map[string]bool{"MDAT_erheben": true}),
+	}
+	referenceDate, _ := timestamp.Default.Parse("2024-01-10 12:00:00")
+
+	actual := Check(consents, []Policy{{Name: "MDAT_erheben"}}, referenceDate, timestamp.Default.Parse)
+	assert.Equal(t, Permit, actual[0].Decision)
+	assert.Equal(t, "1.6.d", *actual[0].Consent.ConsentTemplateKey.Version)
+
+	actual = Check(consents, []Policy{{Name: "MDAT_erheben"}}, referenceDate.Add(time.Second), timestamp.Default.Parse)
+	assert.Equal(t, Deny, actual[0].Decision)
+	assert.Equal(t, "1.7.2", *actual[0].Consent.ConsentTemplateKey.Version)
+}
+
+func TestParseReferenceDate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 11, 12, 0, time.UTC)
+
+	cases := map[string]string{
+		"":                    "2024-03-01 10:11:12",
+		"2024-01-31":          "2024-01-31 23:59:59",
+		"2024-01-31T08:00:00": "2024-01-31 08:00:00",
+	}
+	for in, expected := range cases {
+		actual, err := ParseReferenceDate(in, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, FormatDate(actual))
+	}
+
+	_, err := ParseReferenceDate("31.01.2024", now)
+	assert.Error(t, err)
+}
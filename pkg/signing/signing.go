@@ -0,0 +1,166 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Header is the Kafka header carrying the record's signature
+const Header = "X-Signature"
+
+const algorithm = "EdDSA"
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidKey       = errors.New("invalid signing key")
+)
+
+type protectedHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Content returns the signed content of a record: the length-prefixed key
+// and value followed by the timestamp in milliseconds, as stored by Kafka
+func Content(key, value []byte, timestamp time.Time) []byte {
+	c := make([]byte, 0, 8+len(key)+len(value)+8)
+	c = binary.BigEndian.AppendUint32(c, uint32(len(key)))
+	c = append(c, key...)
+	c = binary.BigEndian.AppendUint32(c, uint32(len(value)))
+	c = append(c, value...)
+	return binary.BigEndian.AppendUint64(c, uint64(timestamp.UnixMilli()))
+}
+
+// Signer signs records with the private key identified by its key id
+type Signer struct {
+	keyId string
+	key   ed25519.PrivateKey
+}
+
+func NewSigner(keyId string, key ed25519.PrivateKey) *Signer {
+	return &Signer{keyId: keyId, key: key}
+}
+
+// LoadSigner reads a PEM encoded PKCS #8 Ed25519 private key
+func LoadSigner(keyId, path string) (*Signer, error) {
+	if keyId == "" {
+		return nil, errors.New("signing key id is required")
+	}
+	der, err := readPem(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKey, path, err)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an Ed25519 key", ErrInvalidKey, path)
+	}
+	return NewSigner(keyId, key), nil
+}
+
+// KeyId returns the id of the signing key
+func (s *Signer) KeyId() string {
+	return s.keyId
+}
+
+// Sign returns the record's signature as compact JWS with detached payload
+func (s *Signer) Sign(key, value []byte, timestamp time.Time) string {
+	h, _ := json.Marshal(protectedHeader{Alg: algorithm, Kid: s.keyId})
+	header := base64.RawURLEncoding.EncodeToString(h)
+	sig := ed25519.Sign(s.key, signingInput(header, Content(key, value, timestamp)))
+	return header + ".." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// Verifier verifies record signatures with the public keys of all known key
+// ids, so records signed with rotated keys remain verifiable
+type Verifier struct {
+	keys map[string]ed25519.PublicKey
+}
+
+func NewVerifier(keys map[string]ed25519.PublicKey) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// LoadVerifier reads all PEM encoded Ed25519 public keys of the directory.
+// The file name without extension is the key id, e.g. 2024-01.pem.
+func LoadVerifier(dir string) (*Verifier, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(files))
+	for _, f := range files {
+		der, err := readPem(f)
+		if err != nil {
+			return nil, err
+		}
+		k, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKey, f, err)
+		}
+		key, ok := k.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an Ed25519 key", ErrInvalidKey, f)
+		}
+		keys[strings.TrimSuffix(filepath.Base(f), ".pem")] = key
+	}
+	return NewVerifier(keys), nil
+}
+
+// Verify checks the signature of the record's key, value and timestamp
+func (v *Verifier) Verify(signature string, key, value []byte, timestamp time.Time) error {
+	header, sig, ok := strings.Cut(signature, "..")
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	h, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var ph protectedHeader
+	if err = json.Unmarshal(h, &ph); err != nil || ph.Alg != algorithm {
+		return ErrInvalidSignature
+	}
+	pub, found := v.keys[ph.Kid]
+	if !found {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, ph.Kid)
+	}
+
+	s, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !ed25519.Verify(pub, signingInput(header, Content(key, value, timestamp)), s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signingInput(header string, content []byte) []byte {
+	return []byte(header + "." + base64.RawURLEncoding.EncodeToString(content))
+}
+
+func readPem(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s contains no PEM data", ErrInvalidKey, path)
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,128 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func writePem(t *testing.T, path, blockType string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	pub, priv := newKey(t)
+	s := NewSigner("2024-01", priv)
+	v := NewVerifier(map[string]ed25519.PublicKey{"2024-01": pub})
+	ts := time.Date(2023, 6, 5, 12, 9, 10, 123456789, time.UTC)
+
+	sig := s.Sign([]byte("key"), []byte("value"), ts)
+
+	assert.NoError(t, v.Verify(sig, []byte("key"), []byte("value"), ts))
+	// Kafka stores timestamps in milliseconds
+	assert.NoError(t, v.Verify(sig, []byte("key"), []byte("value"), ts.Truncate(time.Millisecond)))
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	pub, priv := newKey(t)
+	s := NewSigner("2024-01", priv)
+	v := NewVerifier(map[string]ed25519.PublicKey{"2024-01": pub})
+	ts := time.Now()
+
+	sig := s.Sign([]byte("key"), []byte("value"), ts)
+
+	cases := map[string]error{
+		"key":       v.Verify(sig, []byte("other"), []byte("value"), ts),
+		"value":     v.Verify(sig, []byte("key"), []byte("other"), ts),
+		"timestamp": v.Verify(sig, []byte("key"), []byte("value"), ts.Add(time.Second)),
+		// moving bytes between key and value changes the content
+		"boundary":  v.Verify(sig, []byte("keyv"), []byte("alue"), ts),
+		"malformed": v.Verify("invalid", []byte("key"), []byte("value"), ts),
+	}
+	for name, err := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+}
+
+func TestVerify_Tombstone(t *testing.T) {
+	pub, priv := newKey(t)
+	s := NewSigner("2024-01", priv)
+	v := NewVerifier(map[string]ed25519.PublicKey{"2024-01": pub})
+	ts := time.Now()
+
+	sig := s.Sign([]byte("key"), nil, ts)
+
+	assert.NoError(t, v.Verify(sig, []byte("key"), nil, ts))
+}
+
+func TestVerify_Rotation(t *testing.T) {
+	oldPub, oldPriv := newKey(t)
+	newPub, newPriv := newKey(t)
+	v := NewVerifier(map[string]ed25519.PublicKey{"2023-01": oldPub, "2024-01": newPub})
+	ts := time.Now()
+
+	assert.NoError(t, v.Verify(NewSigner("2023-01", oldPriv).Sign(nil, []byte("value"), ts), nil, []byte("value"), ts))
+	assert.NoError(t, v.Verify(NewSigner("2024-01", newPriv).Sign(nil, []byte("value"), ts), nil, []byte("value"), ts))
+
+	// signed with the new key, but claiming the old key id
+	err := v.Verify(NewSigner("2023-01", newPriv).Sign(nil, []byte("value"), ts), nil, []byte("value"), ts)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	err = v.Verify(NewSigner("2025-01", newPriv).Sign(nil, []byte("value"), ts), nil, []byte("value"), ts)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv := newKey(t)
+	privDer, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDer, _ := x509.MarshalPKIXPublicKey(pub)
+	writePem(t, filepath.Join(dir, "signing-key.key"), "PRIVATE KEY", privDer)
+	writePem(t, filepath.Join(dir, "2024-01.pem"), "PUBLIC KEY", pubDer)
+
+	s, err := LoadSigner("2024-01", filepath.Join(dir, "signing-key.key"))
+	assert.NoError(t, err)
+	v, err := LoadVerifier(dir)
+	assert.NoError(t, err)
+
+	ts := time.Now()
+	assert.Equal(t, "2024-01", s.KeyId())
+	assert.NoError(t, v.Verify(s.Sign([]byte("key"), []byte("value"), ts), []byte("key"), []byte("value"), ts))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "invalid.pem")
+	_ = os.WriteFile(path, []byte("no pem"), 0o600)
+
+	_, err := LoadSigner("2024-01", path)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = LoadSigner("", path)
+	assert.Error(t, err)
+
+	_, err = LoadSigner("2024-01", filepath.Join(dir, "missing.pem"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	_, err = LoadVerifier(dir)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
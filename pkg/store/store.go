@@ -0,0 +1,240 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	bolt "go.etcd.io/bbolt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var consentBucket = []byte("consents")
+
+// Consent is the current state of a signer's consent for a consent template
+type Consent struct {
+	ConsentTemplateKey  notification.ConsentTemplateKey `json:"consentTemplateKey"`
+	SignerIds           []notification.SignerId         `json:"signerIds"`
+	ConsentDate         string                          `json:"consentDate"`
+	CurrentPolicyStates []notification.PolicyState      `json:"currentPolicyStates"`
+	Qc                  *notification.Qc                `json:"qc,omitempty"`
+	UpdatedAt           time.Time                       `json:"updatedAt"`
+}
+
+type ConsentStore interface {
+	Update(d notification.NotificationData, updatedAt time.Time) error
+	Delete(d notification.NotificationData) error
+	Consents(idType, id string) ([]Consent, error)
+}
+
+// RecordReader reads all record values of a topic
+type RecordReader interface {
+	ReadAll(handle func(value []byte, timestamp time.Time) error) error
+}
+
+type BoltStore struct {
+	db *bolt.DB
+	// parses consent dates to compare them by time
+	parse func(string) (time.Time, error)
+}
+
+func Open(path string, parse func(string) (time.Time, error)) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(consentBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db, parse: parse}, nil
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// Update stores the current state of the notification's consent for each of
+// its signer ids, unless a more recent consent of the same template is already
+// stored for the signer
+func (s *BoltStore) Update(d notification.NotificationData, updatedAt time.Time) error {
+	if !hasConsentKey(d) {
+		return errors.New("incomplete consent key")
+	}
+
+	k := d.ConsentKey
+	consent := Consent{
+		ConsentTemplateKey:  *k.ConsentTemplateKey,
+		SignerIds:           k.SignerIds,
+		ConsentDate:         *k.ConsentDate,
+		CurrentPolicyStates: d.CurrentPolicyStates,
+		UpdatedAt:           updatedAt,
+	}
+	if d.Context != nil {
+		consent.Qc = &d.Context.Qc
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(consentBucket)
+		for _, signerId := range k.SignerIds {
+			key := consentKey(signerId.IdType, signerId.Id, *k.ConsentTemplateKey)
+
+			c := consent
+			if v := b.Get(key); v != nil {
+				var existing Consent
+				if err := json.Unmarshal(v, &existing); err == nil {
+					order := s.compareDates(existing.ConsentDate, c.ConsentDate)
+					if order > 0 {
+						// more recent consent already stored
+						continue
+					}
+					if order == 0 && c.Qc == nil {
+						c.Qc = existing.Qc
+					}
+				}
+			}
+
+			v, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			if err = b.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the notification's consent for each of its signer ids
+func (s *BoltStore) Delete(d notification.NotificationData) error {
+	if !hasConsentKey(d) {
+		return errors.New("incomplete consent key")
+	}
+
+	k := d.ConsentKey
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(consentBucket)
+		for _, signerId := range k.SignerIds {
+			key := consentKey(signerId.IdType, signerId.Id, *k.ConsentTemplateKey)
+
+			var existing Consent
+			if v := b.Get(key); v == nil || json.Unmarshal(v, &existing) != nil || s.compareDates(existing.ConsentDate, *k.ConsentDate) != 0 {
+				continue
+			}
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// compareDates orders consent dates by time, as they may differ in offset or
+// fractional seconds. Dates which can't be parsed are compared as strings.
+func (s *BoltStore) compareDates(a, b string) int {
+	ta, errA := s.parse(a)
+	tb, errB := s.parse(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return ta.Compare(tb)
+}
+
+// Consents returns all current consents of the signer
+func (s *BoltStore) Consents(idType, id string) ([]Consent, error) {
+	consents := make([]Consent, 0)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(consentBucket).Cursor()
+		prefix := signerPrefix(idType, id)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var consent Consent
+			if err := json.Unmarshal(v, &consent); err != nil {
+				return err
+			}
+			consents = append(consents, consent)
+		}
+		return nil
+	})
+
+	return consents, err
+}
+
+// Clear removes all stored consents
+func (s *BoltStore) Clear() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(consentBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(consentBucket)
+		return err
+	})
+}
+
+// Rebuild clears the store and replays all records read by the reader. Records
+// are either notification data or consent snapshots.
+func (s *BoltStore) Rebuild(r RecordReader) error {
+	if err := s.Clear(); err != nil {
+		return err
+	}
+
+	count := 0
+	err := r.ReadAll(func(value []byte, timestamp time.Time) error {
+		if value == nil {
+			return nil
+		}
+
+		var r struct {
+			notification.NotificationData
+			Qc  *notification.Qc `json:"qc"`
+			Raw notification.Raw `json:"raw"`
+		}
+		if err := json.Unmarshal(value, &r); err != nil || !hasConsentKey(r.NotificationData) {
+			slog.Warn("Skipping invalid record during consent store rebuild", "error", err)
+			return nil
+		}
+		// restore dates normalized in the output
+		notification.RestoreDates(&r.NotificationData, r.Raw)
+		if r.Context == nil && r.Qc != nil {
+			r.Context = &notification.Context{Qc: *r.Qc}
+		}
+
+		count++
+		return s.Update(r.NotificationData, timestamp)
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Consent store rebuilt", "records", count)
+	return nil
+}
+
+func hasConsentKey(d notification.NotificationData) bool {
+	k := d.ConsentKey
+	return k != nil && k.ConsentDate != nil && k.ConsentTemplateKey != nil &&
+		k.ConsentTemplateKey.DomainName != nil && k.ConsentTemplateKey.Name != nil && k.ConsentTemplateKey.Version != nil
+}
+
+func signerPrefix(idType, id string) []byte {
+	return []byte(idType + "\x00" + id + "\x00")
+}
+
+func consentKey(idType, id string, t notification.ConsentTemplateKey) []byte {
+	return append(signerPrefix(idType, id), []byte(*t.DomainName+"\x00"+*t.Name+"\x00"+*t.Version)...)
+}
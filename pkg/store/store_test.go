@@ -0,0 +1,241 @@
+package store
+
+import (
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *BoltStore {
+	s, err := Open(filepath.Join(t.TempDir(), "data", "consents.db"), timestamp.Default.Parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func testData(consentDate string, templateVersion string, policy bool, qc *notification.Qc) notification.NotificationData {
+	d := notification.NotificationData{
+		ConsentKey: &notification.ConsentKey{
+			ConsentTemplateKey: &notification.ConsentTemplateKey{
+				DomainName: ptr("MII"),
+				Name:       ptr("Patienteneinwilligung MII"),
+				Version:    ptr(templateVersion),
+			},
+			SignerIds: []notification.SignerId{
+				{IdType: "Patienten-ID", Id: "1", OrderNumber: 1},
+				{IdType: "Fall-ID", Id: "2", OrderNumber: 2},
+			},
+			ConsentDate: ptr(consentDate),
+		},
+		CurrentPolicyStates: []notification.PolicyState{
+			{Key: &notification.PolicyStateKey{DomainName: ptr("MII"), Name: ptr("MDAT_erheben"), Version: ptr("1.0")}, Value: policy},
+		},
+	}
+	if qc != nil {
+		d.Context = &notification.Context{Qc: *qc}
+	}
+	return d
+}
+
+func TestUpdate(t *testing.T) {
+	s := openTestStore(t)
+	now := time.Now().UTC()
+
+	err := s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), now)
+
+	assert.NoError(t, err)
+	for _, id := range [][]string{{"Patienten-ID", "1"}, {"Fall-ID", "2"}} {
+		actual, err := s.Consents(id[0], id[1])
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 1) {
+			assert.Equal(t, "2023-05-02 01:57:27", actual[0].ConsentDate)
+			assert.True(t, actual[0].CurrentPolicyStates[0].Value)
+			assert.Equal(t, now, actual[0].UpdatedAt)
+		}
+	}
+}
+
+func TestUpdate_KeepsMostRecentConsent(t *testing.T) {
+	s := openTestStore(t)
+
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), time.Now())
+	_ = s.Update(testData("2023-01-01 00:00:00", "1.6.d", false, nil), time.Now())
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.Len(t, actual, 1)
+	assert.Equal(t, "2023-05-02 01:57:27", actual[0].ConsentDate)
+	assert.True(t, actual[0].CurrentPolicyStates[0].Value)
+}
+
+func TestUpdate_ComparesDatesByTime(t *testing.T) {
+	s := openTestStore(t)
+
+	_ = s.Update(testData("2023-05-02T01:00:00+02:00", "1.6.d", false, nil), time.Now())
+	// more recent, although ordered before as string
+	_ = s.Update(testData("2023-05-01T23:30:00Z", "1.6.d", true, nil), time.Now())
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.Len(t, actual, 1)
+	assert.Equal(t, "2023-05-01T23:30:00Z", actual[0].ConsentDate)
+	assert.True(t, actual[0].CurrentPolicyStates[0].Value)
+}
+
+func TestUpdate_QcState(t *testing.T) {
+	s := openTestStore(t)
+
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), time.Now())
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", false, &notification.Qc{QcPassed: false, Type: "invalidated"}), time.Now())
+	// update without QC context keeps the consent's QC state
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", false, nil), time.Now())
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.Len(t, actual, 1)
+	assert.Equal(t, &notification.Qc{QcPassed: false, Type: "invalidated"}, actual[0].Qc)
+}
+
+func TestUpdate_MultipleTemplates(t *testing.T) {
+	s := openTestStore(t)
+
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), time.Now())
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.7.2", true, nil), time.Now())
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+	other, _ := s.Consents("Patienten-ID", "10")
+
+	assert.Len(t, actual, 2)
+	assert.Empty(t, other)
+}
+
+func TestUpdate_IncompleteConsentKey(t *testing.T) {
+	s := openTestStore(t)
+	d := testData("2023-05-02 01:57:27", "1.6.d", true, nil)
+	d.ConsentKey.ConsentDate = nil
+
+	err := s.Update(d, time.Now())
+
+	assert.EqualError(t, err, "incomplete consent key")
+}
+
+type TestRecordReader struct {
+	values [][]byte
+	err    error
+}
+
+func (r TestRecordReader) ReadAll(handle func(value []byte, timestamp time.Time) error) error {
+	for _, v := range r.values {
+		if err := handle(v, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return r.err
+}
+
+func TestRebuild(t *testing.T) {
+	s := openTestStore(t)
+	_ = s.Update(testData("2023-05-02 01:57:27", "0.1", true, nil), time.Now())
+
+	err := s.Rebuild(TestRecordReader{values: [][]byte{
+		[]byte(`{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"Patienten-ID","id":"1","orderNumber":1}],"consentDate":"2023-05-02 01:57:27"}}`),
+		[]byte("invalid"),
+		[]byte("{}"),
+		nil,
+	}})
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "1.6.d", *actual[0].ConsentTemplateKey.Version)
+	}
+}
+
+func TestRebuild_Error(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Rebuild(TestRecordReader{err: errors.New("test")})
+
+	assert.EqualError(t, err, "test")
+}
+
+func TestDelete(t *testing.T) {
+	s := openTestStore(t)
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), time.Now())
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.7.2", true, nil), time.Now())
+
+	// other consent date of the same template
+	err := s.Delete(testData("2023-01-01 00:00:00", "1.6.d", true, nil))
+	assert.NoError(t, err)
+	actual, _ := s.Consents("Patienten-ID", "1")
+	assert.Len(t, actual, 2)
+
+	err = s.Delete(testData("2023-05-02 01:57:27", "1.6.d", true, nil))
+	assert.NoError(t, err)
+	actual, _ = s.Consents("Fall-ID", "2")
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "1.7.2", *actual[0].ConsentTemplateKey.Version)
+	}
+}
+
+func TestDelete_ComparesDatesByTime(t *testing.T) {
+	s := openTestStore(t)
+	_ = s.Update(testData("2023-05-02 01:57:27", "1.6.d", true, nil), time.Now())
+
+	// same instant in UTC
+	err := s.Delete(testData("2023-05-01T23:57:27Z", "1.6.d", true, nil))
+
+	assert.NoError(t, err)
+	actual, _ := s.Consents("Patienten-ID", "1")
+	assert.Empty(t, actual)
+}
+
+func TestDelete_IncompleteConsentKey(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Delete(notification.NotificationData{})
+
+	assert.EqualError(t, err, "incomplete consent key")
+}
+
+func TestRebuild_Snapshots(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Rebuild(TestRecordReader{values: [][]byte{
+		[]byte(`{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"Patienten-ID","id":"1","orderNumber":1}],"consentDate":"2023-05-02 01:57:27"},"qc":{"qcPassed":true,"type":"valid"},"notificationType":"GICS.SetQcForConsent"}`),
+	}})
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, &notification.Qc{QcPassed: true, Type: "valid"}, actual[0].Qc)
+	}
+}
+
+func TestRebuild_NormalizedDates(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Rebuild(TestRecordReader{values: [][]byte{
+		[]byte(`{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"Patienten-ID","id":"1","orderNumber":1}],"consentDate":"2023-05-01T23:57:27Z"},"raw":{"consentKey.consentDate":"2023-05-02 01:57:27"}}`),
+	}})
+
+	actual, _ := s.Consents("Patienten-ID", "1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "2023-05-02 01:57:27", actual[0].ConsentDate)
+	}
+}
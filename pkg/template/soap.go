@@ -0,0 +1,149 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	soapEnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	gicsNamespace         = "http://cm2.ttp.ganimed.icmvc.emau.org/"
+	// defaultTimeout of lookups, which block the notification
+	defaultTimeout = 10 * time.Second
+)
+
+var ErrNotFound = errors.New("consent template not found")
+
+// SoapResolver resolves template metadata with the gICS SOAP service's
+// getConsentTemplate operation
+type SoapResolver struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewSoapResolver creates a resolver with the configured timeout, or the
+// default timeout if none is set
+func NewSoapResolver(c config.Gics) *SoapResolver {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &SoapResolver{
+		url:      c.Url,
+		user:     c.Auth.User,
+		password: c.Auth.Password,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+type keyDto struct {
+	DomainName string `xml:"domainName"`
+	Name       string `xml:"name"`
+	Version    string `xml:"version"`
+}
+
+func (k keyDto) policyKey() notification.PolicyStateKey {
+	return notification.PolicyStateKey{DomainName: &k.DomainName, Name: &k.Name, Version: &k.Version}
+}
+
+type getConsentTemplateRequest struct {
+	XMLName   xml.Name `xml:"soapenv:Envelope"`
+	SoapEnv   string   `xml:"xmlns:soapenv,attr"`
+	Namespace string   `xml:"xmlns:cm2,attr"`
+	Key       keyDto   `xml:"soapenv:Body>cm2:getConsentTemplate>consentTemplateKey"`
+}
+
+type consentTemplateDto struct {
+	Title           string `xml:"title"`
+	Type            string `xml:"type"`
+	AssignedModules []struct {
+		Module struct {
+			Key      keyDto `xml:"key"`
+			Title    string `xml:"title"`
+			Policies []struct {
+				Key keyDto `xml:"key"`
+			} `xml:"policies"`
+		} `xml:"module"`
+	} `xml:"assignedModules"`
+	ExpirationProperties *struct {
+		FixedDate string `xml:"fixedDate"`
+		Period    string `xml:"period"`
+	} `xml:"expirationProperties"`
+}
+
+type getConsentTemplateResponse struct {
+	Body struct {
+		Fault *struct {
+			Code   string `xml:"faultcode"`
+			String string `xml:"faultstring"`
+		} `xml:"Fault"`
+		Response *struct {
+			Return consentTemplateDto `xml:"return"`
+		} `xml:"getConsentTemplateResponse"`
+	} `xml:"Body"`
+}
+
+func (r *SoapResolver) Resolve(ctx context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error) {
+	body, _ := xml.Marshal(getConsentTemplateRequest{
+		SoapEnv:   soapEnvelopeNamespace,
+		Namespace: gicsNamespace,
+		Key:       keyDto{DomainName: deref(key.DomainName), Name: deref(key.Name), Version: deref(key.Version)},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", `""`)
+	if r.user != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var e getConsentTemplateResponse
+	if err = xml.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return nil, fmt.Errorf("failed to read gICS response (status %d): %w", resp.StatusCode, err)
+	}
+	if f := e.Body.Fault; f != nil {
+		if strings.Contains(f.String, "UnknownConsentTemplate") || strings.Contains(f.String, "UnknownDomain") {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, f.String)
+		}
+		return nil, fmt.Errorf("gICS fault: %s", f.String)
+	}
+	if e.Body.Response == nil {
+		return nil, fmt.Errorf("unexpected gICS response (status %d)", resp.StatusCode)
+	}
+
+	return e.Body.Response.Return.metadata(), nil
+}
+
+func (t consentTemplateDto) metadata() *notification.TemplateMetadata {
+	m := &notification.TemplateMetadata{Title: t.Title, Type: t.Type}
+	for _, a := range t.AssignedModules {
+		module := notification.TemplateModule{Key: a.Module.Key.policyKey(), Title: a.Module.Title}
+		for _, p := range a.Module.Policies {
+			module.Policies = append(module.Policies, p.Key.policyKey())
+		}
+		m.Modules = append(m.Modules, module)
+	}
+	if e := t.ExpirationProperties; e != nil && (e.FixedDate != "" || e.Period != "") {
+		m.Expiration = &notification.TemplateExpiration{FixedDate: e.FixedDate, Period: e.Period}
+	}
+	return m
+}
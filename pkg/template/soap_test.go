@@ -0,0 +1,109 @@
+package template
+
+import (
+	"context"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const templateResponse = `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <ns2:getConsentTemplateResponse xmlns:ns2="http://cm2.ttp.ganimed.icmvc.emau.org/">
+      <return>
+        <key><domainName>MII</domainName><name>Patienteneinwilligung MII</name><version>1.6.d</version></key>
+        <title>Patienteneinwilligung der Medizininformatik-Initiative</title>
+        <type>CONSENT</type>
+        <assignedModules>
+          <module>
+            <key><domainName>MII</domainName><name>PATDAT</name><version>1.0</version></key>
+            <title>Patientendaten</title>
+            <policies><key><domainName>MII</domainName><name>IDAT_erheben</name><version>1.0</version></key></policies>
+            <policies><key><domainName>MII</domainName><name>MDAT_erheben</name><version>1.1</version></key></policies>
+          </module>
+        </assignedModules>
+        <expirationProperties><period>P30Y</period></expirationProperties>
+      </return>
+    </ns2:getConsentTemplateResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func gicsStub(t *testing.T, status int, response string) *SoapResolver {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "gics", user)
+		assert.Equal(t, "secret", password)
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "<cm2:getConsentTemplate><consentTemplateKey><domainName>MII</domainName><name>Patienteneinwilligung MII</name><version>1.6.d</version></consentTemplateKey></cm2:getConsentTemplate>")
+
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewSoapResolver(config.Gics{Url: srv.URL, Timeout: time.Second, Auth: config.Auth{User: "gics", Password: "secret"}})
+}
+
+func TestSoapResolver_Resolve(t *testing.T) {
+	r := gicsStub(t, http.StatusOK, templateResponse)
+
+	actual, err := r.Resolve(context.Background(), testKey("1.6.d"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Patienteneinwilligung der Medizininformatik-Initiative", actual.Title)
+	assert.Equal(t, "CONSENT", actual.Type)
+	if assert.Len(t, actual.Modules, 1) {
+		assert.Equal(t, "PATDAT", *actual.Modules[0].Key.Name)
+		assert.Len(t, actual.Modules[0].Policies, 2)
+		assert.Equal(t, "MDAT_erheben", *actual.Modules[0].Policies[1].Name)
+	}
+	assert.Equal(t, &notification.TemplateExpiration{Period: "P30Y"}, actual.Expiration)
+}
+
+func TestSoapResolver_UnknownTemplate(t *testing.T) {
+	r := gicsStub(t, http.StatusInternalServerError,
+		`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault><faultcode>soap:Server</faultcode><faultstring>UnknownConsentTemplateException: not found</faultstring></soap:Fault></soap:Body></soap:Envelope>`)
+
+	_, err := r.Resolve(context.Background(), testKey("1.6.d"))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSoapResolver_Errors(t *testing.T) {
+	cases := map[string]string{
+		"fault": `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault><faultstring>InvalidParameterException</faultstring></soap:Fault></soap:Body></soap:Envelope>`,
+		"empty": `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body/></soap:Envelope>`,
+		"html":  `<html><body>Bad Gateway`,
+	}
+
+	for name, response := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := gicsStub(t, http.StatusInternalServerError, response)
+
+			_, err := r.Resolve(context.Background(), testKey("1.6.d"))
+
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestNewSoapResolver_DefaultTimeout(t *testing.T) {
+	assert.Equal(t, defaultTimeout, NewSoapResolver(config.Gics{}).client.Timeout)
+	assert.Equal(t, time.Second, NewSoapResolver(config.Gics{Timeout: time.Second}).client.Timeout)
+}
+
+func TestSoapResolver_Unavailable(t *testing.T) {
+	r := NewSoapResolver(config.Gics{Url: "http://localhost:1", Timeout: time.Second})
+
+	_, err := r.Resolve(context.Background(), testKey("1.6.d"))
+
+	assert.Error(t, err)
+}
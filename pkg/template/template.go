@@ -0,0 +1,98 @@
+package template
+
+import (
+	"context"
+	"gics-to-kafka/pkg/notification"
+	"sync"
+	"time"
+)
+
+// Resolver resolves the metadata of a consent template
+type Resolver interface {
+	Resolve(ctx context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error)
+}
+
+type cacheEntry struct {
+	metadata *notification.TemplateMetadata
+	expires  time.Time
+}
+
+// call is an in-flight resolution, which concurrent lookups of the same
+// template wait for
+type call struct {
+	done     chan struct{}
+	metadata *notification.TemplateMetadata
+	err      error
+}
+
+// Cache caches resolved template metadata for the TTL. Failed resolutions
+// are not cached. Concurrent lookups of a missing template share a single
+// resolution.
+type Cache struct {
+	resolver Resolver
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*call
+}
+
+func NewCache(r Resolver, ttl time.Duration) *Cache {
+	return &Cache{resolver: r, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry), inflight: make(map[string]*call)}
+}
+
+func (c *Cache) Resolve(ctx context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error) {
+	k := cacheKey(key)
+
+	c.mu.Lock()
+	if e, ok := c.entries[k]; ok && c.now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.metadata, nil
+	}
+	if cl, ok := c.inflight[k]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.metadata, cl.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	cl := &call{done: make(chan struct{})}
+	c.inflight[k] = cl
+	c.mu.Unlock()
+
+	cl.metadata, cl.err = c.resolver.Resolve(ctx, key)
+
+	c.mu.Lock()
+	// an invalidated resolution may be outdated and isn't cached
+	if c.inflight[k] == cl {
+		delete(c.inflight, k)
+		if cl.err == nil {
+			c.entries[k] = cacheEntry{metadata: cl.metadata, expires: c.now().Add(c.ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(cl.done)
+	return cl.metadata, cl.err
+}
+
+// Invalidate removes the template's metadata, e.g. after it was changed in gICS
+func (c *Cache) Invalidate(key notification.ConsentTemplateKey) {
+	c.mu.Lock()
+	delete(c.entries, cacheKey(key))
+	delete(c.inflight, cacheKey(key))
+	c.mu.Unlock()
+}
+
+func cacheKey(k notification.ConsentTemplateKey) string {
+	return deref(k.DomainName) + "\x00" + deref(k.Name) + "\x00" + deref(k.Version)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
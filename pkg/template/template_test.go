@@ -0,0 +1,133 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type StubResolver struct {
+	calls int
+	err   error
+}
+
+func (r *StubResolver) Resolve(_ context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &notification.TemplateMetadata{Title: *key.Name}, nil
+}
+
+func testKey(version string) notification.ConsentTemplateKey {
+	domain, name := "MII", "Patienteneinwilligung MII"
+	return notification.ConsentTemplateKey{DomainName: &domain, Name: &name, Version: &version}
+}
+
+func TestCache_Resolve(t *testing.T) {
+	r := &StubResolver{}
+	c := NewCache(r, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	actual, err := c.Resolve(context.Background(), testKey("1.6.d"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Patienteneinwilligung MII", actual.Title)
+
+	_, _ = c.Resolve(context.Background(), testKey("1.6.d"))
+	assert.Equal(t, 1, r.calls)
+
+	_, _ = c.Resolve(context.Background(), testKey("1.7.2"))
+	assert.Equal(t, 2, r.calls)
+
+	// expired
+	now = now.Add(time.Minute)
+	_, _ = c.Resolve(context.Background(), testKey("1.6.d"))
+	assert.Equal(t, 3, r.calls)
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	r := &StubResolver{}
+	c := NewCache(r, time.Hour)
+
+	_, _ = c.Resolve(context.Background(), testKey("1.6.d"))
+	c.Invalidate(testKey("1.6.d"))
+	_, _ = c.Resolve(context.Background(), testKey("1.6.d"))
+
+	assert.Equal(t, 2, r.calls)
+}
+
+func TestCache_ErrorNotCached(t *testing.T) {
+	r := &StubResolver{err: errors.New("unavailable")}
+	c := NewCache(r, time.Hour)
+
+	_, err := c.Resolve(context.Background(), testKey("1.6.d"))
+	assert.Error(t, err)
+
+	r.err = nil
+	actual, err := c.Resolve(context.Background(), testKey("1.6.d"))
+	assert.NoError(t, err)
+	assert.NotNil(t, actual)
+	assert.Equal(t, 2, r.calls)
+}
+
+type BlockingResolver struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *BlockingResolver) Resolve(_ context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error) {
+	r.calls.Add(1)
+	<-r.release
+	return &notification.TemplateMetadata{Title: *key.Name}, nil
+}
+
+func TestCache_ConcurrentResolve(t *testing.T) {
+	r := &BlockingResolver{release: make(chan struct{})}
+	c := NewCache(r, time.Hour)
+
+	var wg sync.WaitGroup
+	titles := make([]string, 10)
+	for i := range titles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m, err := c.Resolve(context.Background(), testKey("1.6.d")); err == nil {
+				titles[i] = m.Title
+			}
+		}()
+	}
+	// let the other lookups join the in-flight resolution before releasing it
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.inflight) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(r.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), r.calls.Load())
+	for _, title := range titles {
+		assert.Equal(t, "Patienteneinwilligung MII", title)
+	}
+}
+
+func TestCache_ResolveCanceled(t *testing.T) {
+	r := &BlockingResolver{release: make(chan struct{})}
+	c := NewCache(r, time.Hour)
+	go func() { _, _ = c.Resolve(context.Background(), testKey("1.6.d")) }()
+	assert.Eventually(t, func() bool { return r.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Resolve(ctx, testKey("1.6.d"))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	close(r.release)
+}
@@ -0,0 +1,74 @@
+package timestamp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	// embed the timezone database for images without OS timezone files
+	_ "time/tzdata"
+)
+
+const DefaultZone = "Europe/Berlin"
+
+// DefaultLayouts are accepted by default. Fractional seconds are accepted
+// after the seconds field, even if the layout doesn't contain them.
+var DefaultLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// Default parses the default layouts in the default zone
+var Default = &Parser{loc: mustLoadLocation(DefaultZone), layouts: DefaultLayouts}
+
+var ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+// Parser parses timestamps with one of the accepted layouts. Timestamps
+// without offset are interpreted in the source timezone.
+type Parser struct {
+	loc     *time.Location
+	layouts []string
+}
+
+func NewParser(zone string, layouts []string) (*Parser, error) {
+	if zone == "" {
+		zone = DefaultZone
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+	if len(layouts) == 0 {
+		layouts = DefaultLayouts
+	}
+	return &Parser{loc: loc, layouts: layouts}, nil
+}
+
+func mustLoadLocation(zone string) *time.Location {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Location is the source timezone
+func (p *Parser) Location() *time.Location {
+	if p == nil {
+		return Default.loc
+	}
+	return p.loc
+}
+
+// Parse tries all accepted layouts in order. A nil parser uses the defaults.
+func (p *Parser) Parse(s string) (time.Time, error) {
+	if p == nil {
+		p = Default
+	}
+	for _, l := range p.layouts {
+		if t, err := time.ParseInLocation(l, s, p.loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidTimestamp, s)
+}
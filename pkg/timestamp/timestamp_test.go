@@ -0,0 +1,69 @@
+package timestamp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type ParseTestCase struct {
+	name     string
+	value    string
+	expected time.Time
+}
+
+func TestParser_Parse(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	p, err := NewParser("Europe/Berlin", nil)
+	assert.NoError(t, err)
+
+	cases := []ParseTestCase{
+		{name: "createdAt", value: "2023-06-05T12:09:10", expected: time.Date(2023, 6, 5, 12, 9, 10, 0, berlin)},
+		{name: "fractionalSeconds", value: "2023-06-05T12:09:10.463125126", expected: time.Date(2023, 6, 5, 12, 9, 10, 463125126, berlin)},
+		{name: "consentDate", value: "2023-05-02 01:57:27", expected: time.Date(2023, 5, 2, 1, 57, 27, 0, berlin)},
+		{name: "offset", value: "2023-06-05T12:09:10+01:00", expected: time.Date(2023, 6, 5, 11, 9, 10, 0, time.UTC)},
+		{name: "utc", value: "2023-06-05T12:09:10.5Z", expected: time.Date(2023, 6, 5, 12, 9, 10, 500000000, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := p.Parse(c.value)
+
+			assert.NoError(t, err)
+			assert.True(t, c.expected.Equal(actual), "expected %s, got %s", c.expected, actual)
+		})
+	}
+}
+
+func TestParser_ParseInvalid(t *testing.T) {
+	_, err := Default.Parse("05.06.2023 12:09")
+
+	assert.ErrorIs(t, err, ErrInvalidTimestamp)
+}
+
+func TestParser_Layouts(t *testing.T) {
+	p, _ := NewParser("UTC", []string{"02.01.2006 15:04"})
+
+	actual, err := p.Parse("05.06.2023 12:09")
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 6, 5, 12, 9, 0, 0, time.UTC), actual)
+	_, err = p.Parse("2023-06-05T12:09:10")
+	assert.Error(t, err)
+}
+
+func TestNewParser_InvalidZone(t *testing.T) {
+	_, err := NewParser("Europe/Marburg", nil)
+
+	assert.Error(t, err)
+}
+
+func TestParser_Nil(t *testing.T) {
+	var p *Parser
+
+	actual, err := p.Parse("2023-06-05T12:09:10")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", actual.Location().String())
+	assert.Equal(t, Default.Location(), p.Location())
+}
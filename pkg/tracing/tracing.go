@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"gics-to-kafka/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"log/slog"
+)
+
+// Setup configures the global W3C trace context propagator and, if enabled,
+// a tracer provider exporting spans via OTLP/HTTP. The returned function
+// flushes and stops the exporter.
+func Setup(ctx context.Context, c config.App) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if !c.Tracing.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Tracing.Endpoint)}
+	if c.Tracing.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := NewTracerProvider(c, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+
+	slog.Info("Tracing enabled", "endpoint", c.Tracing.Endpoint, "sample-ratio", c.Tracing.SampleRatio)
+
+	return tp.Shutdown, nil
+}
+
+// NewTracerProvider creates a tracer provider for the application with the
+// configured sampler and the given span processor options
+func NewTracerProvider(c config.App, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(c.Name))
+
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.Tracing.SampleRatio))),
+	}, opts...)...)
+}
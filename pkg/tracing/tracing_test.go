@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"gics-to-kafka/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.App{})
+
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+}
+
+func TestSetup_Enabled(t *testing.T) {
+	c := config.App{Name: "test", Tracing: config.Tracing{
+		Enabled:     true,
+		Endpoint:    "localhost:4318",
+		Insecure:    true,
+		SampleRatio: 1,
+	}}
+
+	shutdown, err := Setup(context.Background(), c)
+
+	assert.NoError(t, err)
+	_, isSdk := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.True(t, isSdk)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	cases := []struct {
+		name     string
+		ratio    float64
+		expected int
+	}{
+		{name: "sampled", ratio: 1, expected: 1},
+		{name: "notSampled", ratio: 0, expected: 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := NewTracerProvider(config.App{Name: "test", Tracing: config.Tracing{SampleRatio: c.ratio}},
+				sdktrace.WithSyncer(exporter))
+
+			_, span := tp.Tracer("test").Start(context.Background(), "test")
+			span.End()
+
+			assert.Len(t, exporter.GetSpans(), c.expected)
+		})
+	}
+}
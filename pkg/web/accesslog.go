@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/logging"
+	"log/slog"
+)
+
+// consentsRoute contains a signer id, which must not be logged
+const consentsRoute = "/consents/:idType/:id"
+
+// accessLogHandler logs the route instead of the path of requests to routes with
+// signer ids, and masks the id parameter
+type accessLogHandler struct {
+	slog.Handler
+}
+
+func newAccessLogHandler(h slog.Handler) *accessLogHandler {
+	return &accessLogHandler{Handler: h}
+}
+
+func (h *accessLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "request" && a.Value.Kind() == slog.KindGroup {
+			a = slog.Attr{Key: a.Key, Value: slog.GroupValue(redactRequest(a.Value.Group())...)}
+		}
+		redacted.AddAttrs(a)
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h *accessLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &accessLogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *accessLogHandler) WithGroup(name string) slog.Handler {
+	return &accessLogHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redactRequest(attrs []slog.Attr) []slog.Attr {
+	var route string
+	for _, a := range attrs {
+		if a.Key == "route" {
+			route = a.Value.String()
+		}
+	}
+	if route != consentsRoute {
+		return attrs
+	}
+
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		switch a.Key {
+		case "path":
+			a = slog.String(a.Key, route)
+		case "params":
+			if params, ok := a.Value.Any().(map[string]string); ok {
+				masked := make(map[string]string, len(params))
+				for k, v := range params {
+					masked[k] = v
+				}
+				masked["id"] = logging.RedactedValue
+				a = slog.Any(a.Key, masked)
+			}
+		}
+		redacted[i] = a
+	}
+	return redacted
+}
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"bytes"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"path/filepath"
+	"testing"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestAccessLog_ConsentsRoute(t *testing.T) {
+	st, _ := store.Open(filepath.Join(t.TempDir(), "consents.db"), timestamp.Default.Parse)
+	t.Cleanup(func() { _ = st.Close() })
+	buf := captureLog(t)
+
+	s := testServer(&RecordingProducer{})
+	s.store = st
+	_ = serve(s, "GET", "/consents/Patienten-ID/4711", nil)
+
+	assert.NotContains(t, buf.String(), "4711")
+	assert.Contains(t, buf.String(), `"path":"/consents/:idType/:id"`)
+	assert.Contains(t, buf.String(), `"params":{"id":"***","idType":"Patienten-ID"}`)
+}
+
+func TestAccessLog_OtherRoutes(t *testing.T) {
+	buf := captureLog(t)
+
+	_ = serve(testServer(&RecordingProducer{}), "GET", "/health", nil)
+
+	assert.Contains(t, buf.String(), `"path":"/health"`)
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/correlation"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Failure is a recently failed notification
+type Failure struct {
+	Time      time.Time `json:"time"`
+	RequestId string    `json:"requestId,omitempty"`
+	ClientId  string    `json:"clientId,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	Code      ErrorCode `json:"code"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// monitor tracks the runtime state shown by the admin API. All methods are
+// no-ops on a nil monitor.
+type monitor struct {
+	inFlight atomic.Int64
+	mu       sync.Mutex
+	// ring buffer of recent failures
+	failures []Failure
+	next     int
+	full     bool
+}
+
+func newMonitor(failures int) *monitor {
+	return &monitor{failures: make([]Failure, max(failures, 0))}
+}
+
+// track counts a delivery in flight until the returned function is called
+func (m *monitor) track() func() {
+	if m == nil {
+		return func() {}
+	}
+	m.inFlight.Add(1)
+	return func() { m.inFlight.Add(-1) }
+}
+
+func (m *monitor) addFailure(f Failure) {
+	if m == nil || len(m.failures) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures[m.next] = f
+	m.next = (m.next + 1) % len(m.failures)
+	m.full = m.full || m.next == 0
+}
+
+// recentFailures returns the kept failures, most recent first
+func (m *monitor) recentFailures() []Failure {
+	failures := make([]Failure, 0)
+	if m == nil {
+		return failures
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := m.next
+	if m.full {
+		n = len(m.failures)
+	}
+	for i := 1; i <= n; i++ {
+		failures = append(failures, m.failures[(m.next-i+len(m.failures))%len(m.failures)])
+	}
+	return failures
+}
+
+// recordOutcome records a received notification's outcome in the audit journal
+// and the recent failures. It returns the problem to respond with, which is
+// JOURNAL_UNAVAILABLE if the outcome could not be journaled.
+func (s Server) recordOutcome(ctx context.Context, received time.Time, body []byte, n *notification.Notification, tp *cKafka.TopicPartition, p *Problem) *Problem {
+	if err := s.recordJournal(ctx, received, body, n, tp, p); err != nil {
+		p = newProblem(JournalUnavailable, "")
+	}
+	if p == nil {
+		return nil
+	}
+
+	f := Failure{Time: received, RequestId: correlation.FromContext(ctx), Code: p.Code, Detail: p.Detail}
+	if n != nil {
+		f.ClientId, f.Type = deref(n.ClientId), deref(n.Type)
+	}
+	s.monitor.addFailure(f)
+	return p
+}
+
+func (s Server) setupAdminRoutes(r *gin.Engine) {
+	admin := r.Group("/admin", basicAuth(s.adminAccounts))
+	admin.GET("/config", s.handleAdminConfig)
+	admin.GET("/producer", s.handleAdminProducer)
+	admin.GET("/failures", s.handleAdminFailures)
+	admin.GET("/stats", s.handleAdminStats)
+	admin.GET("/metadata", s.handleAdminMetadata)
+	admin.GET("/maintenance", s.handleAdminMaintenance)
+	admin.POST("/pause", s.handleAdminPause)
+	admin.POST("/resume", s.handleAdminResume)
+
+	admin.GET("/pprof/", gin.WrapF(pprof.Index))
+	admin.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	admin.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	admin.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	admin.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	admin.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	admin.GET("/pprof/:profile", func(c *gin.Context) {
+		pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// handleAdminConfig responds with the effective configuration without secrets,
+// including reloaded settings
+func (s Server) handleAdminConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, s.appliedConfig().Masked())
+}
+
+func (s Server) handleAdminProducer(c *gin.Context) {
+	i, ok := s.inspector(c)
+	if !ok {
+		return
+	}
+
+	var inFlight int64
+	if s.monitor != nil {
+		inFlight = s.monitor.inFlight.Load()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"queueLength": i.QueueLength(),
+		"inFlight":    inFlight,
+		"healthy":     s.producer.IsHealthy(),
+	})
+}
+
+func (s Server) handleAdminFailures(c *gin.Context) {
+	c.JSON(http.StatusOK, s.monitor.recentFailures())
+}
+
+// handleAdminStats responds with the latest librdkafka statistics
+func (s Server) handleAdminStats(c *gin.Context) {
+	i, ok := s.inspector(c)
+	if !ok {
+		return
+	}
+
+	stats := i.Stats()
+	if stats == nil {
+		abortWithProblem(c, newProblem(NotFound, "statistics are disabled (kafka.statistics-interval)"))
+		return
+	}
+	c.Data(http.StatusOK, "application/json", stats)
+}
+
+type brokerMetadata struct {
+	Id   int32  `json:"id"`
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type partitionMetadata struct {
+	Id       int32   `json:"id"`
+	Leader   int32   `json:"leader"`
+	Replicas []int32 `json:"replicas"`
+	Isrs     []int32 `json:"isrs"`
+	Error    string  `json:"error,omitempty"`
+}
+
+type topicMetadata struct {
+	Partitions []partitionMetadata `json:"partitions"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// handleAdminMetadata responds with the cluster's brokers and topics
+func (s Server) handleAdminMetadata(c *gin.Context) {
+	i, ok := s.inspector(c)
+	if !ok {
+		return
+	}
+
+	md, err := i.Metadata()
+	if err != nil {
+		abortWithProblem(c, newProblem(KafkaUnavailable, err.Error()))
+		return
+	}
+
+	brokers := make([]brokerMetadata, 0, len(md.Brokers))
+	for _, b := range md.Brokers {
+		brokers = append(brokers, brokerMetadata{Id: b.ID, Host: b.Host, Port: b.Port})
+	}
+	topics := make(map[string]topicMetadata, len(md.Topics))
+	for name, t := range md.Topics {
+		tm := topicMetadata{Partitions: make([]partitionMetadata, 0, len(t.Partitions)), Error: kafkaError(t.Error)}
+		for _, p := range t.Partitions {
+			tm.Partitions = append(tm.Partitions, partitionMetadata{
+				Id: p.ID, Leader: p.Leader, Replicas: p.Replicas, Isrs: p.Isrs, Error: kafkaError(p.Error),
+			})
+		}
+		topics[name] = tm
+	}
+	c.JSON(http.StatusOK, gin.H{"brokers": brokers, "topics": topics})
+}
+
+// inspector returns the producer's internals or aborts if not available
+func (s Server) inspector(c *gin.Context) (kafka.Inspector, bool) {
+	i, ok := s.producer.(kafka.Inspector)
+	if !ok {
+		abortWithProblem(c, newProblem(InternalError, "producer cannot be inspected"))
+	}
+	return i, ok
+}
+
+func kafkaError(err cKafka.Error) string {
+	if err.Code() == cKafka.ErrNoError {
+		return ""
+	}
+	return err.Error()
+}
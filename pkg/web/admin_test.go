@@ -0,0 +1,173 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type InspectableProducer struct {
+	RecordingProducer
+	stats       json.RawMessage
+	metadataErr error
+}
+
+func (p *InspectableProducer) QueueLength() int {
+	return 2
+}
+
+func (p *InspectableProducer) Stats() json.RawMessage {
+	return p.stats
+}
+
+func (p *InspectableProducer) Metadata() (*cKafka.Metadata, error) {
+	if p.metadataErr != nil {
+		return nil, p.metadataErr
+	}
+	return &cKafka.Metadata{
+		Brokers: []cKafka.BrokerMetadata{{ID: 1, Host: "kafka", Port: 9092}},
+		Topics: map[string]cKafka.TopicMetadata{
+			"notifications": {Topic: "notifications", Partitions: []cKafka.PartitionMetadata{{ID: 0, Leader: 1, Replicas: []int32{1}, Isrs: []int32{1}}}},
+		},
+	}, nil
+}
+
+func adminTestServer(p *InspectableProducer) Server {
+	s := testServer(p)
+	s.config.App.Http.Auth.Password = "notification-secret"
+	s.config.App.Http.Admin = config.Admin{Enabled: true, Auth: config.Auth{User: "admin", Password: "admin-secret"}}
+	s.monitor = newMonitor(2)
+	return s
+}
+
+func TestAdmin_Auth(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "GET", "/admin/config", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/admin/config", nil, withAuth("admin", "admin-secret")).Code)
+
+	// disabled
+	s.config.App.Http.Admin.Enabled = false
+	assert.Equal(t, http.StatusNotFound, serve(s, "GET", "/admin/config", nil, withAuth("admin", "admin-secret")).Code)
+}
+
+func TestAdmin_Config(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+
+	w := serve(s, "GET", "/admin/config", nil, withAuth("admin", "admin-secret"))
+
+	assert.Contains(t, w.Body.String(), `"output-topic":"notifications"`)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
+
+func TestAdmin_ConfigReloaded(t *testing.T) {
+	s := reloadable(adminTestServer(&InspectableProducer{}))
+
+	c := s.config
+	c.Kafka.OutputTopic = "routed"
+	assert.NoError(t, s.reload(context.Background(), "test", func() (*config.AppConfig, error) { return &c, nil }))
+
+	w := serve(s, "GET", "/admin/config", nil, withAuth("admin", "admin-secret"))
+
+	assert.Contains(t, w.Body.String(), `"output-topic":"routed"`)
+}
+
+func TestAdmin_Producer(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+
+	w := serve(s, "GET", "/admin/producer", nil, withAuth("admin", "admin-secret"))
+
+	assert.JSONEq(t, `{"queueLength":2,"inFlight":0,"healthy":false}`, w.Body.String())
+}
+
+func TestAdmin_Failures(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+	s.config.App.UnknownTypes = config.UnknownTypesReject
+	post := func(body string) {
+		_ = serve(s, "POST", "/notification", []byte(body))
+	}
+
+	post("{")
+	post(strings.Replace(validNotification, "gICS_Web", "Other", 1))
+	post(validNotification)
+	post(strings.Replace(validNotification, "GICS.AddConsent", "GICS.Unknown", 1))
+
+	w := serve(s, "GET", "/admin/failures", nil, withAuth("admin", "admin-secret"))
+
+	var actual []Failure
+	_ = json.Unmarshal(w.Body.Bytes(), &actual)
+	// only the last two failures are kept, most recent first
+	if assert.Len(t, actual, 2) {
+		assert.Equal(t, UnknownType, actual[0].Code)
+		assert.Equal(t, "GICS.Unknown", actual[0].Type)
+		assert.NotEmpty(t, actual[0].RequestId)
+		assert.Equal(t, InvalidClientId, actual[1].Code)
+		assert.Equal(t, "Other", actual[1].ClientId)
+	}
+}
+
+func TestMonitor_RecentFailures(t *testing.T) {
+	m := newMonitor(3)
+	for i := range 4 {
+		m.addFailure(Failure{Time: time.Unix(int64(i), 0)})
+	}
+
+	actual := m.recentFailures()
+
+	if assert.Len(t, actual, 3) {
+		assert.Equal(t, int64(3), actual[0].Time.Unix())
+		assert.Equal(t, int64(1), actual[2].Time.Unix())
+	}
+	assert.Empty(t, (*monitor)(nil).recentFailures())
+	assert.Empty(t, newMonitor(0).recentFailures())
+}
+
+func TestAdmin_Stats(t *testing.T) {
+	p := &InspectableProducer{}
+	s := adminTestServer(p)
+
+	assert.Equal(t, http.StatusNotFound, serve(s, "GET", "/admin/stats", nil, withAuth("admin", "admin-secret")).Code)
+
+	p.stats = json.RawMessage(`{"msg_cnt":1}`)
+	w := serve(s, "GET", "/admin/stats", nil, withAuth("admin", "admin-secret"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"msg_cnt":1}`, w.Body.String())
+}
+
+func TestAdmin_Metadata(t *testing.T) {
+	p := &InspectableProducer{}
+	s := adminTestServer(p)
+
+	w := serve(s, "GET", "/admin/metadata", nil, withAuth("admin", "admin-secret"))
+
+	assert.JSONEq(t, `{
+		"brokers": [{"id":1,"host":"kafka","port":9092}],
+		"topics": {"notifications": {"partitions": [{"id":0,"leader":1,"replicas":[1],"isrs":[1]}]}}
+	}`, w.Body.String())
+
+	p.metadataErr = errors.New("unavailable")
+	w = serve(s, "GET", "/admin/metadata", nil, withAuth("admin", "admin-secret"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestAdmin_Pprof(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/admin/pprof/", nil, withAuth("admin", "admin-secret")).Code)
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/admin/pprof/heap", nil, withAuth("admin", "admin-secret")).Code)
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/admin/pprof/cmdline", nil, withAuth("admin", "admin-secret")).Code)
+}
+
+func TestAdmin_NotInspectable(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+	s.producer = &RecordingProducer{}
+
+	assert.Equal(t, http.StatusInternalServerError, serve(s, "GET", "/admin/producer", nil, withAuth("admin", "admin-secret")).Code)
+}
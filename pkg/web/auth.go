@@ -0,0 +1,46 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"gics-to-kafka/pkg/config"
+	"github.com/gin-gonic/gin"
+)
+
+type authUserKey struct{}
+
+// accounts returns the configured user and all additional accounts. Accounts
+// without user or password are skipped, they would allow empty credentials.
+func accounts(a config.Auth) gin.Accounts {
+	accounts := gin.Accounts{}
+	for _, account := range append([]config.Account{{User: a.User, Password: a.Password}}, a.Accounts...) {
+		if account.User != "" && account.Password != "" {
+			accounts[account.User] = account.Password
+		}
+	}
+	return accounts
+}
+
+// basicAuth works like gin.BasicAuth but responds with a problem body on failure.
+// The accounts are looked up per request, so they can be reloaded.
+func basicAuth(accounts func() gin.Accounts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, password, ok := c.Request.BasicAuth()
+		expected, found := accounts()[user]
+		if !ok || !found || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+			abortWithProblem(c, newProblem(Unauthorized, ""))
+			return
+		}
+
+		c.Set(gin.AuthUserKey, user)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), authUserKey{}, user))
+		c.Next()
+	}
+}
+
+// authUser returns the authenticated user of the request context
+func authUser(ctx context.Context) string {
+	user, _ := ctx.Value(authUserKey{}).(string)
+	return user
+}
@@ -0,0 +1,226 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBatchConcurrency limits the goroutines and in-flight records per batch request
+const maxBatchConcurrency = 1000
+
+func validateBatch(c config.Batch) error {
+	var errs []error
+	if c.Concurrency < 1 || c.Concurrency > maxBatchConcurrency {
+		errs = append(errs, fmt.Errorf("concurrency must be between 1 and %d", maxBatchConcurrency))
+	}
+	if c.MaxSize < 1 {
+		errs = append(errs, errors.New("max-size must be greater than zero"))
+	}
+	if c.MaxBodySize < 1 {
+		errs = append(errs, errors.New("max-body-size must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+type BatchResult struct {
+	Index  int      `json:"index"`
+	Status int      `json:"status"`
+	Error  *Problem `json:"error,omitempty"`
+	Offset *int64   `json:"offset,omitempty"`
+}
+
+type batchItem struct {
+	// notification as received
+	raw          []byte
+	notification *notification.Notification
+	err          *Problem
+}
+
+func (s Server) handleNotifications(c *gin.Context) {
+	received := time.Now()
+
+	batch := s.config.App.Http.Batch
+	body := c.Request.Body
+	if batch.MaxBodySize > 0 {
+		body = http.MaxBytesReader(c.Writer, body, int64(batch.MaxBodySize)*1024*1024)
+	}
+	items, err := readBatch(body, c.ContentType(), batch.MaxSize)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to read notification batch", "error", err)
+		p := s.recordOutcome(c.Request.Context(), received, nil, nil, nil, newProblem(InvalidBatch, err.Error()))
+		abortWithProblem(c, p)
+		return
+	}
+
+	slog.DebugContext(c.Request.Context(), "Notification batch received", "size", len(items))
+
+	results := s.processBatch(c, items, received)
+
+	status := http.StatusCreated
+	for _, r := range results {
+		if r.Status != http.StatusCreated {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+	c.JSON(status, results)
+}
+
+func (s Server) processBatch(c *gin.Context, items []batchItem, received time.Time) []BatchResult {
+	results := make([]BatchResult, len(items))
+
+	concurrency := s.config.App.Http.Batch.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	ctx := c.Request.Context()
+	id := requestId(c)
+	for i, item := range items {
+		results[i].Index = i
+
+		if item.err != nil {
+			slog.ErrorContext(ctx, "Failed to bind JSON", "index", i, "error", item.err.Detail)
+			p := s.recordOutcome(ctx, received, item.raw, nil, nil, item.err)
+			p.RequestId = id
+			results[i].Status = p.Status
+			results[i].Error = p
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *BatchResult, raw []byte, n notification.Notification) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			tp, p := s.processNotification(ctx, n, received)
+			p = s.recordOutcome(ctx, received, raw, &n, tp, p)
+			if p != nil {
+				p.RequestId = id
+				r.Status = p.Status
+				r.Error = p
+				return
+			}
+			offset := int64(tp.Offset)
+			r.Status = http.StatusCreated
+			r.Offset = &offset
+		}(&results[i], item.raw, *item.notification)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// readBatch parses the request body either as a JSON array of notifications or
+// as newline delimited JSON (NDJSON), one notification per line. Reading stops
+// as soon as the batch has more than maxSize notifications, 0 is unlimited.
+func readBatch(body io.Reader, contentType string, maxSize int) ([]batchItem, error) {
+	r := bufio.NewReader(body)
+
+	if contentType != "application/x-ndjson" {
+		// skip leading whitespace to check for a JSON array
+		for {
+			b, err := r.Peek(1)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, errors.New("empty notification batch")
+				}
+				return nil, err
+			}
+			if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+				if b[0] == '[' {
+					return readArray(r, maxSize)
+				}
+				break
+			}
+			_, _ = r.ReadByte()
+		}
+	}
+
+	return readLines(r, maxSize)
+}
+
+func readArray(r io.Reader, maxSize int) ([]batchItem, error) {
+	dec := json.NewDecoder(r)
+	// opening bracket
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	items := []batchItem{}
+	for dec.More() {
+		if err := checkBatchSize(len(items), maxSize); err != nil {
+			return nil, err
+		}
+		var m json.RawMessage
+		if err := dec.Decode(&m); err != nil {
+			return nil, err
+		}
+		items = append(items, parseItem(m))
+	}
+
+	// closing bracket
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func readLines(r *bufio.Reader, maxSize int) ([]batchItem, error) {
+	var items []batchItem
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		if l := bytes.TrimSpace(line); len(l) > 0 {
+			if err := checkBatchSize(len(items), maxSize); err != nil {
+				return nil, err
+			}
+			items = append(items, parseItem(l))
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("empty notification batch")
+	}
+	return items, nil
+}
+
+// checkBatchSize fails if another notification exceeds the batch's maxSize
+func checkBatchSize(read, maxSize int) error {
+	if maxSize > 0 && read >= maxSize {
+		return fmt.Errorf("batch exceeds the maximum of %d notifications", maxSize)
+	}
+	return nil
+}
+
+func parseItem(data []byte) batchItem {
+	var n notification.Notification
+	if err := json.Unmarshal(data, &n); err != nil {
+		return batchItem{raw: data, err: newProblem(InvalidJson, err.Error())}
+	}
+	return batchItem{raw: data, notification: &n}
+}
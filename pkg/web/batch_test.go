@@ -0,0 +1,160 @@
+package web
+
+import (
+	"encoding/json"
+	"gics-to-kafka/pkg/config"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const validNotification = `{"type":"GICS.AddConsent","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"consentKey\":{\"consentTemplateKey\":{\"domainName\":\"MII\",\"name\":\"Patienteneinwilligung MII\",\"version\":\"1.6.d\"},\"signerIds\":[{\"idType\":\"test\",\"id\":\"1\",\"orderNumber\":1}],\"consentDate\":\"2023-05-02 01:57:27\"}}"}`
+
+type BatchTestCase struct {
+	name        string
+	contentType string
+	body        string
+	statusCode  int
+	expected    []BatchResult
+}
+
+func TestNotificationsHandler(t *testing.T) {
+	offset := int64(42)
+
+	cases := []BatchTestCase{
+		{
+			name:       "jsonArray",
+			body:       "[" + validNotification + "," + validNotification + "]",
+			statusCode: http.StatusCreated,
+			expected: []BatchResult{
+				{Index: 0, Status: http.StatusCreated, Offset: &offset},
+				{Index: 1, Status: http.StatusCreated, Offset: &offset},
+			},
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			body:        validNotification + "\n\n" + validNotification + "\n",
+			statusCode:  http.StatusCreated,
+			expected: []BatchResult{
+				{Index: 0, Status: http.StatusCreated, Offset: &offset},
+				{Index: 1, Status: http.StatusCreated, Offset: &offset},
+			},
+		},
+		{
+			name:       "ndjsonWithoutContentType",
+			body:       validNotification + "\n" + validNotification,
+			statusCode: http.StatusCreated,
+			expected: []BatchResult{
+				{Index: 0, Status: http.StatusCreated, Offset: &offset},
+				{Index: 1, Status: http.StatusCreated, Offset: &offset},
+			},
+		},
+		{
+			name:       "partialSuccess",
+			body:       "[" + validNotification + `, {"clientId": "test"}, 42]`,
+			statusCode: http.StatusMultiStatus,
+			expected: []BatchResult{
+				{Index: 0, Status: http.StatusCreated, Offset: &offset},
+				{Index: 1, Status: http.StatusBadRequest, Error: &Problem{Code: IncompleteData}},
+				{Index: 2, Status: http.StatusBadRequest, Error: &Problem{Code: InvalidJson}},
+			},
+		},
+		{
+			name:       "invalidArray",
+			body:       "[" + validNotification,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "emptyBody",
+			body:       "  \n",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := testServer(TestProducer{kafkaResponse: cKafka.Message{
+				TopicPartition: cKafka.TopicPartition{Offset: cKafka.Offset(offset)},
+			}})
+			s.config.App.Http.Batch = config.Batch{Concurrency: 2}
+			var opts []func(*http.Request)
+			if c.contentType != "" {
+				opts = append(opts, withHeader("Content-Type", c.contentType))
+			}
+			w := serve(s, "POST", "/notifications", []byte(c.body), opts...)
+
+			assert.Equal(t, c.statusCode, w.Code)
+			if c.expected != nil {
+				var actual []BatchResult
+				_ = json.Unmarshal(w.Body.Bytes(), &actual)
+
+				assert.Len(t, actual, len(c.expected))
+				for i, e := range c.expected {
+					assert.Equal(t, e.Index, actual[i].Index)
+					assert.Equal(t, e.Status, actual[i].Status)
+					assert.Equal(t, e.Offset, actual[i].Offset)
+					if e.Error != nil && assert.NotNil(t, actual[i].Error) {
+						assert.Equal(t, e.Error.Code, actual[i].Error.Code)
+						assert.Equal(t, w.Header().Get(RequestIdHeader), actual[i].Error.RequestId)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadBatch_LeadingWhitespace(t *testing.T) {
+	items, err := readBatch(strings.NewReader("\n  ["+validNotification+"]"), "application/json", 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Nil(t, items[0].err)
+}
+
+func TestNotificationsHandler_MaxSize(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.App.Http.Batch.MaxSize = 1
+
+	w := serve(s, "POST", "/notifications", []byte("["+validNotification+","+validNotification+"]"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(InvalidBatch))
+	assert.Empty(t, p.records)
+}
+
+func TestReadBatch_MaxSize(t *testing.T) {
+	// the rest of the body is not read
+	_, err := readBatch(strings.NewReader("["+validNotification+","+validNotification+",{"), "application/json", 1)
+	assert.ErrorContains(t, err, "maximum of 1 notifications")
+
+	_, err = readBatch(strings.NewReader(validNotification+"\n"+validNotification+"\n{"), "application/x-ndjson", 1)
+	assert.ErrorContains(t, err, "maximum of 1 notifications")
+
+	items, err := readBatch(strings.NewReader("[]"), "application/json", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestNotificationsHandler_MaxBodySize(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.App.Http.Batch.MaxBodySize = 1
+
+	w := serve(s, "POST", "/notifications", []byte("["+validNotification+","+strings.Repeat(" ", 1024*1024)+"]"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(InvalidBatch))
+	assert.Empty(t, p.records)
+}
+
+func TestValidateBatch(t *testing.T) {
+	assert.NoError(t, validateBatch(config.Batch{Concurrency: 50, MaxSize: 1000, MaxBodySize: 10}))
+	assert.Error(t, validateBatch(config.Batch{Concurrency: 0, MaxSize: 1000, MaxBodySize: 10}))
+	assert.Error(t, validateBatch(config.Batch{Concurrency: maxBatchConcurrency + 1, MaxSize: 1000, MaxBodySize: 10}))
+	assert.Error(t, validateBatch(config.Batch{Concurrency: 50, MaxBodySize: 10}))
+	assert.Error(t, validateBatch(config.Batch{Concurrency: 50, MaxSize: 1000}))
+}
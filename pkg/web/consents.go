@@ -0,0 +1,72 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/envelope"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+func newStore(c config.AppConfig, kms envelope.KMS, timestamps *timestamp.Parser) *store.BoltStore {
+	st, err := store.Open(c.Store.Path, timestamps.Parse)
+	if err != nil {
+		slog.Error("Failed to open consent store. Terminating", "path", c.Store.Path, "error", err)
+		os.Exit(1)
+	}
+
+	if c.Store.Rebuild {
+		// prefer the compacted snapshot topic, which also reflects deletions
+		topic := c.Kafka.OutputTopic
+		if c.Kafka.SnapshotTopic != "" {
+			topic = c.Kafka.SnapshotTopic
+		}
+
+		slog.Info("Rebuilding consent store from topic", "topic", topic)
+		var r store.RecordReader = kafka.NewTopicReader(c.Kafka, topic)
+		if kms != nil {
+			r = decryptingReader{RecordReader: r, kms: kms}
+		}
+		if err = st.Rebuild(r); err != nil {
+			slog.Error("Failed to rebuild consent store. Terminating", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	return st
+}
+
+func (s Server) updateStore(ctx context.Context, notificationType string, d notification.NotificationData, updatedAt time.Time) {
+	if s.store == nil {
+		return
+	}
+
+	// Kafka is the source of truth, the store can be rebuilt from the topic
+	var err error
+	if removesConsent(notificationType) {
+		err = s.store.Delete(d)
+	} else {
+		err = s.store.Update(d, updatedAt)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to update consent store", "error", err)
+	}
+}
+
+func (s Server) handleConsents(c *gin.Context) {
+	consents, err := s.store.Consents(c.Param("idType"), c.Param("id"))
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to read consents from store", "error", err)
+		abortWithProblem(c, newProblem(InternalError, "Failed to read consents"))
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type FailingStore struct{}
+
+func (FailingStore) Update(_ notification.NotificationData, _ time.Time) error {
+	return errors.New("test")
+}
+
+func (FailingStore) Delete(_ notification.NotificationData) error {
+	return errors.New("test")
+}
+
+func (FailingStore) Consents(_, _ string) ([]store.Consent, error) {
+	return nil, errors.New("test")
+}
+
+func TestHandleConsents(t *testing.T) {
+	st, _ := store.Open(filepath.Join(t.TempDir(), "consents.db"), timestamp.Default.Parse)
+	t.Cleanup(func() { _ = st.Close() })
+	s := testServer(&RecordingProducer{})
+	s.store = st
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = serve(s, "GET", "/consents/test/1", nil)
+
+	var actual []store.Consent
+	_ = json.Unmarshal(w.Body.Bytes(), &actual)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.Len(t, actual, 1) {
+		assert.Equal(t, "2023-05-02 01:57:27", actual[0].ConsentDate)
+		assert.Equal(t, "1.6.d", *actual[0].ConsentTemplateKey.Version)
+	}
+
+	w = serve(s, "GET", "/consents/test/2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestHandleConsents_StoreError(t *testing.T) {
+	s := testServer(&RecordingProducer{})
+	s.store = FailingStore{}
+
+	// failing store updates don't fail the notification request
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = serve(s, "GET", "/consents/test/1", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandleConsents_StoreDisabled(t *testing.T) {
+	s := testServer(&RecordingProducer{})
+
+	w := serve(s, "GET", "/consents/test/1", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/envelope"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"log/slog"
+	"os"
+	"time"
+)
+
+var errEncryptionFailed = errors.New("failed to encrypt identifying fields")
+
+func newKMS(c config.Encryption) envelope.KMS {
+	kms, err := envelope.LoadLocalKMS(c.KeyId, c.KeyDir)
+	if err != nil {
+		slog.Error("Failed to load encryption master keys. Terminating", "path", c.KeyDir, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Encrypting identifying fields", "keyId", c.KeyId)
+	return kms
+}
+
+// validateEncryption rejects outputs which would contain the identifying fields
+// in plaintext, the raw and notification formats and dead letters are sent
+// unchanged. E-PIX person data has no fields to encrypt its identity by.
+func validateEncryption(c config.AppConfig) error {
+	if !c.Kafka.Encryption.Enabled {
+		return nil
+	}
+
+	var errs []error
+	plaintext := func(format string) bool {
+		return format == config.FormatRaw || format == config.FormatNotification
+	}
+	if plaintext(c.App.OutputFormat) {
+		errs = append(errs, fmt.Errorf("output format %s can't be encrypted", c.App.OutputFormat))
+	}
+	for i, cl := range c.App.Clients {
+		if plaintext(cl.Format) {
+			errs = append(errs, fmt.Errorf("client %d: output format %s can't be encrypted", i, cl.Format))
+		}
+		src := notification.SourceOf(cl.Id)
+		if cl.Source != "" {
+			src = notification.SourceByName(cl.Source)
+		}
+		if src == notification.EPIX {
+			errs = append(errs, fmt.Errorf("client %d: E-PIX person data can't be encrypted", i))
+		}
+	}
+	if c.App.UnknownTypes == config.UnknownTypesDeadLetter {
+		errs = append(errs, errors.New("dead letters can't be encrypted"))
+	}
+	return errors.Join(errs...)
+}
+
+// encryptTransform encrypts all identifying fields with a new data key and
+// returns the envelope as section. E-PIX person data, accepted by client id
+// prefix without configured clients, is rejected.
+func (s Server) encryptTransform(ctx context.Context) notification.Transform {
+	return func(c any) (string, any, error) {
+		if _, ok := c.(*notification.PersonData); ok {
+			return "", nil, fmt.Errorf("%w: E-PIX person data can't be encrypted", errEncryptionFailed)
+		}
+		id, ok := c.(notification.Identifying)
+		if !ok {
+			return "", nil, nil
+		}
+
+		var env *envelope.Envelope
+		for path, value := range id.IdentifyingFields() {
+			if value == nil || *value == "" {
+				continue
+			}
+			if env == nil {
+				var err error
+				if env, err = envelope.New(ctx, s.encryption); err != nil {
+					return "", nil, fmt.Errorf("%w: %v", errEncryptionFailed, err)
+				}
+			}
+			*value = env.Encrypt(path, *value)
+		}
+		if env == nil {
+			return "", nil, nil
+		}
+		return envelope.Key, env.Header(), nil
+	}
+}
+
+// decryptingReader decrypts records before they are replayed to the store
+type decryptingReader struct {
+	store.RecordReader
+	kms envelope.KMS
+}
+
+func (r decryptingReader) ReadAll(handle func(value []byte, timestamp time.Time) error) error {
+	return r.RecordReader.ReadAll(func(value []byte, timestamp time.Time) error {
+		v, err := envelope.Decrypt(context.Background(), r.kms, value)
+		if err != nil {
+			slog.Warn("Skipping record which cannot be decrypted during consent store rebuild", "error", err)
+			return nil
+		}
+		return handle(v, timestamp)
+	})
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/envelope"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testKMS(t *testing.T) *envelope.LocalKMS {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	kms, err := envelope.NewLocalKMS("2024-01", map[string][]byte{"2024-01": key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kms
+}
+
+func TestEncryption(t *testing.T) {
+	p := &RecordingProducer{}
+	kms := testKMS(t)
+	s := testServer(p)
+	s.config.Kafka.SnapshotTopic = "snapshots"
+	s.encryption = kms
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if !assert.Len(t, p.records, 2) {
+		return
+	}
+	for _, r := range p.records {
+		assert.NotContains(t, string(r.Value), `"id":"1"`)
+		assert.Contains(t, string(r.Value), `"encryption":{"alg":"A256GCM","kid":"2024-01"`)
+
+		actual, err := envelope.Decrypt(context.Background(), kms, r.Value)
+		assert.NoError(t, err)
+		assert.Contains(t, string(actual), `"id":"1"`)
+	}
+
+	// key is derived from the plain signer id
+	plain := &RecordingProducer{}
+	_ = serve(testServer(plain), "POST", "/notification", []byte(validNotification))
+	assert.Equal(t, plain.records[0].Key, p.records[0].Key)
+}
+
+func TestEncryption_Sources(t *testing.T) {
+	p := &RecordingProducer{}
+	kms := testKMS(t)
+	s := testServer(p)
+	s.encryption = kms
+
+	w := serve(s, "POST", "/notification", []byte(`{"type":"GPAS.InsertValuePseudonymPair","clientId":"gPAS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"domainName\":\"MII\",\"originalValue\":\"1001\",\"pseudonym\":\"psn\"}"}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 1) {
+		assert.NotContains(t, string(p.records[0].Value), "1001")
+		actual, err := envelope.Decrypt(context.Background(), kms, p.records[0].Value)
+		assert.NoError(t, err)
+		assert.Contains(t, string(actual), `"originalValue":"1001"`)
+	}
+
+	// identity can't be encrypted
+	w = serve(s, "POST", "/notification", []byte(`{"type":"EPIX.AddPerson","clientId":"E-PIX_Web","createdAt":"2023-06-05T12:09:10","data":"{\"domainName\":\"MII\",\"mpiId\":\"1001\"}"}`))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(EncryptionFailed))
+	assert.Len(t, p.records, 1)
+}
+
+func TestEncryption_Failed(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	// unwrap only
+	s.encryption, _ = envelope.NewLocalKMS("", nil)
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(EncryptionFailed))
+	assert.Empty(t, p.records)
+}
+
+type StubRecordReader struct {
+	values [][]byte
+}
+
+func (r StubRecordReader) ReadAll(handle func(value []byte, timestamp time.Time) error) error {
+	for _, v := range r.values {
+		if err := handle(v, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDecryptingReader(t *testing.T) {
+	p := &RecordingProducer{}
+	kms := testKMS(t)
+	s := testServer(p)
+	s.encryption = kms
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	r := decryptingReader{
+		RecordReader: StubRecordReader{values: [][]byte{p.records[0].Value, nil, p.records[0].Value}},
+		kms:          testKMS(t),
+	}
+	var values [][]byte
+	_ = decryptingReader{RecordReader: StubRecordReader{values: [][]byte{p.records[0].Value, nil}}, kms: kms}.
+		ReadAll(func(value []byte, _ time.Time) error {
+			values = append(values, value)
+			return nil
+		})
+
+	if assert.Len(t, values, 2) {
+		assert.Contains(t, string(values[0]), `"id":"1"`)
+		assert.Nil(t, values[1])
+	}
+
+	// records encrypted with unknown keys are skipped
+	values = nil
+	_ = r.ReadAll(func(value []byte, _ time.Time) error {
+		values = append(values, value)
+		return nil
+	})
+	assert.Equal(t, [][]byte{nil}, values)
+}
+
+func TestValidateEncryption(t *testing.T) {
+	c := config.AppConfig{Kafka: config.Kafka{Encryption: config.Encryption{Enabled: true}}}
+	assert.NoError(t, validateEncryption(c))
+
+	c.App.OutputFormat = config.FormatRaw
+	c.App.Clients = []config.Client{
+		{Id: "gICS_Web", Format: config.FormatPayload},
+		{Id: "gICS_Other", Format: config.FormatNotification},
+		{Id: "E-PIX_Web"},
+		{Id: "gPAS_Web"},
+	}
+	c.App.UnknownTypes = config.UnknownTypesDeadLetter
+
+	err := validateEncryption(c)
+	assert.ErrorContains(t, err, "output format raw")
+	assert.ErrorContains(t, err, "client 1: output format notification")
+	assert.ErrorContains(t, err, "client 2: E-PIX")
+	assert.NotContains(t, err.Error(), "client 3")
+	assert.ErrorContains(t, err, "dead letters")
+
+	c.Kafka.Encryption.Enabled = false
+	assert.NoError(t, validateEncryption(c))
+}
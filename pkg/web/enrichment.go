@@ -0,0 +1,30 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/notification"
+	"log/slog"
+)
+
+// enrichTemplate adds the consent template's metadata to consent notifications
+// and invalidates cached metadata on template changes. Notifications are sent
+// without metadata if it cannot be resolved.
+func (s Server) enrichTemplate(ctx context.Context, payload notification.Payload) {
+	if s.templates == nil {
+		return
+	}
+
+	switch d := payload.(type) {
+	case *notification.TemplateData:
+		s.templates.Invalidate(*d.ConsentTemplateKey)
+	case *notification.NotificationData:
+		ctx, span := tracer().Start(ctx, "resolve consent template")
+		m, err := s.templates.Resolve(ctx, *d.ConsentKey.ConsentTemplateKey)
+		endSpan(span, err)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to resolve consent template metadata", "error", err)
+			return
+		}
+		d.Template = m
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/template"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type StubTemplateResolver struct {
+	calls      int
+	err        error
+	expiration *notification.TemplateExpiration
+}
+
+func (r *StubTemplateResolver) Resolve(_ context.Context, key notification.ConsentTemplateKey) (*notification.TemplateMetadata, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &notification.TemplateMetadata{Title: *key.Name + " " + *key.Version, Type: "CONSENT", Expiration: r.expiration}, nil
+}
+
+const templateNotification = `{"type":"GICS.UpdateConsentTemplate","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"consentTemplateKey\":{\"domainName\":\"MII\",\"name\":\"Patienteneinwilligung MII\",\"version\":\"1.6.d\"}}"}`
+
+func TestEnrichTemplate(t *testing.T) {
+	p := &RecordingProducer{}
+	r := &StubTemplateResolver{}
+	s := testServer(p)
+	s.templates = template.NewCache(r, time.Hour)
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, string(p.records[0].Value), `"template":{"title":"Patienteneinwilligung MII 1.6.d","type":"CONSENT"}`)
+	assert.Equal(t, 1, r.calls)
+
+	// template change notification invalidates the cache
+	_ = serve(s, "POST", "/notification", []byte(templateNotification))
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, 2, r.calls)
+	assert.NotContains(t, string(p.records[2].Value), `"template"`)
+}
+
+func TestEnrichTemplate_Unavailable(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.templates = template.NewCache(&StubTemplateResolver{err: errors.New("unavailable")}, time.Hour)
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, string(p.records[0].Value), `"template"`)
+}
+
+func TestEnrichTemplate_Disabled(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.NotContains(t, string(p.records[0].Value), `"template"`)
+}
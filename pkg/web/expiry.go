@@ -0,0 +1,119 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/expiry"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	"log/slog"
+	"os"
+)
+
+// validateExpiry rejects a check interval the scheduler's ticker can't run with
+func validateExpiry(c config.Expiry) error {
+	if c.Enabled && c.Interval <= 0 {
+		return errors.New("interval must be greater than zero")
+	}
+	return nil
+}
+
+func newScheduler(c config.AppConfig) *expiry.Scheduler {
+	if !c.Enrichment.Enabled {
+		slog.Warn("Consent expiry requires template enrichment, no consents will expire")
+	}
+
+	sc, err := expiry.Open(c.Expiry.Path)
+	if err != nil {
+		slog.Error("Failed to open consent expiry timers. Terminating", "path", c.Expiry.Path, "error", err)
+		os.Exit(1)
+	}
+	return sc
+}
+
+// removesConsent reports whether the notification type ends a consent
+func removesConsent(notificationType string) bool {
+	return notificationType == notification.DeleteConsentType || notificationType == notification.ConsentExpiredType
+}
+
+// scheduleExpiry schedules the consent's expiration according to its template's
+// validity, or cancels it if the consent was deleted. The expiration is sent to
+// the topic with the key and headers of the consent's record r.
+func (s Server) scheduleExpiry(ctx context.Context, notificationType string, d *notification.NotificationData, r kafka.Record) {
+	if s.expiry == nil {
+		return
+	}
+
+	var err error
+	if notificationType == notification.DeleteConsentType {
+		err = s.expiry.Cancel(d.Key())
+	} else if d.Template != nil {
+		consentDate, perr := s.timestamps.Parse(*d.ConsentKey.ConsentDate)
+		if perr != nil {
+			slog.WarnContext(ctx, "Unable to schedule consent expiry", "error", perr)
+			return
+		}
+		expiresAt, ok, perr := expiry.ExpiresAt(consentDate, d.Template.Expiration, s.timestamps.Parse)
+		if perr != nil {
+			slog.WarnContext(ctx, "Unable to schedule consent expiry", "error", perr)
+			return
+		}
+		if !ok {
+			return
+		}
+		err = s.expiry.Schedule(expiry.Timer{
+			Key: d.Key(), Data: *d, ExpiresAt: expiresAt, Topic: r.Topic, RecordKey: r.Key, Headers: r.Headers,
+		})
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to update consent expiry timer", "error", err)
+	}
+}
+
+// expireConsent sends a synthetic expiration event for the timer's consent to
+// the topic and with the key and headers of the consent's notifications. Its policies are no longer granted,
+// the states at expiry become the previous ones.
+func (s Server) expireConsent(ctx context.Context, t expiry.Timer) error {
+	if s.maintenance.paused() {
+		return errPaused
+	}
+
+	ctx, span := tracer().Start(ctx, "expire consent")
+	defer span.End()
+
+	d := t.Data
+	expiredType := notification.ConsentExpiredType
+	d.Type = &expiredType
+	d.PreviousPolicyStates = d.CurrentPolicyStates
+	d.CurrentPolicyStates = make([]notification.PolicyState, len(d.PreviousPolicyStates))
+	for i, ps := range d.PreviousPolicyStates {
+		d.CurrentPolicyStates[i] = notification.PolicyState{Key: ps.Key, Value: false}
+	}
+
+	topic, key := t.Topic, t.RecordKey
+	if topic == "" {
+		// timers scheduled before topics were recorded
+		topic, key = s.current().outputTopic, []byte(d.Key())
+	}
+	msg, p := s.marshalOutput(ctx, &d, topic)
+	if p != nil {
+		recordError(span, p)
+		return p
+	}
+	m, p := s.produce(ctx, "produce expiration", kafka.Record{
+		Topic: topic, Key: key, Timestamp: t.ExpiresAt, Value: msg, Headers: t.Headers,
+	})
+	if p != nil {
+		return p
+	}
+	slog.InfoContext(ctx, "Consent expired", "expiresAt", t.ExpiresAt)
+
+	if s.config.Kafka.SnapshotTopic != "" {
+		if p = s.sendSnapshot(ctx, expiredType, &d, m.Timestamp, t.Headers); p != nil {
+			return p
+		}
+	}
+	s.updateStore(ctx, expiredType, d, m.Timestamp)
+	return nil
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/expiry"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/template"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func expiryTestServer(t *testing.T, p *RecordingProducer) Server {
+	sc, err := expiry.Open(filepath.Join(t.TempDir(), "expiry.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sc.Close() })
+
+	s := testServer(p)
+	s.templates = template.NewCache(&StubTemplateResolver{expiration: &notification.TemplateExpiration{Period: "P1Y"}}, time.Hour)
+	s.expiry = sc
+	return s
+}
+
+func TestExpireConsent(t *testing.T) {
+	p := &RecordingProducer{}
+	s := expiryTestServer(t, p)
+	s.config.Kafka.SnapshotTopic = "snapshots"
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	timers, _ := s.expiry.Due(time.Now())
+	if !assert.Len(t, timers, 1) {
+		return
+	}
+	expected, _ := s.timestamps.Parse("2024-05-02 01:57:27")
+	assert.True(t, expected.Equal(timers[0].ExpiresAt))
+
+	err := s.expireConsent(context.Background(), timers[0])
+
+	assert.NoError(t, err)
+	if assert.Len(t, p.records, 4) {
+		expired, tombstone := p.records[2], p.records[3]
+		assert.Equal(t, "notifications", expired.Topic)
+		assert.Equal(t, p.records[0].Key, expired.Key)
+		assert.Equal(t, p.records[0].Headers, expired.Headers)
+		assert.True(t, expected.Equal(expired.Timestamp))
+		assert.Contains(t, string(expired.Value), `"type":"GICS.ConsentExpired"`)
+
+		assert.Equal(t, "snapshots", tombstone.Topic)
+		assert.Equal(t, p.records[0].Key, tombstone.Key)
+		assert.Equal(t, p.records[0].Headers, tombstone.Headers)
+		assert.Nil(t, tombstone.Value)
+	}
+}
+
+func TestExpireConsent_ClientRouting(t *testing.T) {
+	p := &RecordingProducer{}
+	s := expiryTestServer(t, p)
+	s.config.App.Clients = []config.Client{{Id: "gICS_Web", Source: "gICS", Topic: "consents", Key: config.KeyNone}}
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+	timers, _ := s.expiry.Due(time.Now())
+	if !assert.Len(t, timers, 1) {
+		return
+	}
+
+	assert.NoError(t, s.expireConsent(context.Background(), timers[0]))
+	if assert.Len(t, p.records, 2) {
+		assert.Equal(t, "consents", p.records[1].Topic)
+		assert.Nil(t, p.records[1].Key)
+	}
+}
+
+func TestScheduleExpiry_Delete(t *testing.T) {
+	p := &RecordingProducer{}
+	s := expiryTestServer(t, p)
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+	_ = serve(s, "POST", "/notification",
+		[]byte(strings.Replace(validNotification, "GICS.AddConsent", notification.DeleteConsentType, 1)))
+
+	timers, _ := s.expiry.Due(time.Now())
+	assert.Empty(t, timers)
+}
+
+func TestScheduleExpiry_NoValidity(t *testing.T) {
+	p := &RecordingProducer{}
+	s := expiryTestServer(t, p)
+	s.templates = template.NewCache(&StubTemplateResolver{}, time.Hour)
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	timers, _ := s.expiry.Due(time.Now())
+	assert.Empty(t, timers)
+}
+
+func TestValidateExpiry(t *testing.T) {
+	assert.NoError(t, validateExpiry(config.Expiry{}))
+	assert.NoError(t, validateExpiry(config.Expiry{Enabled: true, Interval: time.Minute}))
+	assert.Error(t, validateExpiry(config.Expiry{Enabled: true}))
+	assert.Error(t, validateExpiry(config.Expiry{Enabled: true, Interval: -time.Second}))
+}
+
+func TestExpireConsent_PolicyStates(t *testing.T) {
+	p := &RecordingProducer{}
+	s := expiryTestServer(t, p)
+
+	_ = serve(s, "POST", "/notification", []byte(policyNotification))
+	timers, _ := s.expiry.Due(time.Now())
+	if !assert.Len(t, timers, 1) {
+		return
+	}
+
+	err := s.expireConsent(context.Background(), timers[0])
+
+	assert.NoError(t, err)
+	if assert.Len(t, p.records, 2) {
+		var actual notification.NotificationData
+		_ = json.Unmarshal(p.records[1].Value, &actual)
+		assert.Equal(t, timers[0].Data.CurrentPolicyStates, actual.PreviousPolicyStates)
+		if assert.Len(t, actual.CurrentPolicyStates, 2) {
+			for _, ps := range actual.CurrentPolicyStates {
+				assert.False(t, ps.Value)
+			}
+		}
+		// the timer's data is unchanged
+		assert.True(t, timers[0].Data.CurrentPolicyStates[0].Value)
+	}
+}
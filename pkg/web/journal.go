@@ -0,0 +1,67 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/journal"
+	"gics-to-kafka/pkg/notification"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"log/slog"
+	"os"
+	"time"
+)
+
+func newJournal(c config.Journal) *journal.Journal {
+	j, err := journal.Open(c.Path, int64(c.MaxSize)*1024*1024)
+	if err != nil {
+		slog.Error("Failed to open audit journal. Terminating", "path", c.Path, "error", err)
+		os.Exit(1)
+	}
+	return j
+}
+
+// recordJournal appends the outcome of a received notification to the audit
+// journal. body is the notification as received, nil if it could not be read,
+// and n is nil if it could not be parsed.
+func (s Server) recordJournal(ctx context.Context, received time.Time, body []byte, n *notification.Notification, tp *cKafka.TopicPartition, p *Problem) error {
+	if s.journal == nil {
+		return nil
+	}
+
+	e := journal.Entry{Time: received, User: authUser(ctx), Outcome: journal.OutcomeAccepted}
+	if body != nil {
+		h := sha256.Sum256(body)
+		e.ContentHash = hex.EncodeToString(h[:])
+	}
+	if n != nil {
+		e.ClientId = deref(n.ClientId)
+		e.Type = deref(n.Type)
+	}
+	if p != nil {
+		e.Outcome = string(p.Code)
+	}
+	if tp != nil {
+		offset := int64(tp.Offset)
+		e.Topic, e.Partition, e.Offset = deref(tp.Topic), &tp.Partition, &offset
+	}
+
+	if err := s.journal.Append(e); err != nil {
+		slog.ErrorContext(ctx, "Failed to write audit journal", "error", err)
+		return err
+	}
+	return nil
+}
+
+// journalHealthy reports whether the last journal entry was written
+func (s Server) journalHealthy() bool {
+	return s.journal == nil || s.journal.Err() == nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
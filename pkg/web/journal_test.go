@@ -0,0 +1,92 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"gics-to-kafka/pkg/journal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func journalEntries(t *testing.T, path string) []journal.Entry {
+	var entries []journal.Entry
+	if err := journal.Read(path, func(_ string, _ int, e journal.Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestRecordJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, _ := journal.Open(path, 0)
+	t.Cleanup(func() { _ = j.Close() })
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.journal = j
+
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+	_ = serve(s, "POST", "/notification", []byte("{"))
+	_ = serve(s, "POST", "/notification", []byte(strings.Replace(validNotification, "gICS_Web", "Other", 1)))
+	_ = serve(s, "POST", "/notifications", []byte("["+validNotification+",{]"))
+	_ = serve(s, "POST", "/notifications", []byte(validNotification+"\n{"))
+
+	entries := journalEntries(t, path)
+	if assert.Len(t, entries, 6) {
+		accepted := entries[0]
+		assert.Equal(t, "test", accepted.User)
+		assert.Equal(t, "gICS_Web", accepted.ClientId)
+		assert.Equal(t, "GICS.AddConsent", accepted.Type)
+		// hash of the body as received
+		h := sha256.Sum256([]byte(validNotification))
+		assert.Equal(t, hex.EncodeToString(h[:]), accepted.ContentHash)
+		assert.Equal(t, journal.OutcomeAccepted, accepted.Outcome)
+		assert.Equal(t, "notifications", accepted.Topic)
+		assert.NotNil(t, accepted.Offset)
+
+		assert.Equal(t, string(InvalidJson), entries[1].Outcome)
+		h = sha256.Sum256([]byte("{"))
+		assert.Equal(t, hex.EncodeToString(h[:]), entries[1].ContentHash)
+		assert.Equal(t, string(InvalidClientId), entries[2].Outcome)
+		assert.Equal(t, "Other", entries[2].ClientId)
+		assert.Equal(t, string(InvalidBatch), entries[3].Outcome)
+		assert.Empty(t, entries[3].ContentHash)
+
+		// batch items
+		outcomes := []string{entries[4].Outcome, entries[5].Outcome}
+		assert.ElementsMatch(t, []string{journal.OutcomeAccepted, string(InvalidJson)}, outcomes)
+	}
+
+	count, err := journal.Verify(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+}
+
+func TestRecordJournal_Unavailable(t *testing.T) {
+	dir := t.TempDir()
+	j, _ := journal.Open(filepath.Join(dir, "journal.jsonl"), 0)
+	_ = j.Close()
+	s := testServer(&RecordingProducer{TestProducer: TestProducer{healthy: true}})
+	s.journal = j
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), string(JournalUnavailable))
+
+	w = serve(s, "POST", "/notifications", []byte("["+validNotification+"]"))
+
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	assert.Contains(t, w.Body.String(), string(JournalUnavailable))
+
+	s.maintenance = &maintenance{}
+	w = serve(s, "GET", "/health/ready", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"ready":false,"paused":false,"healthy":true,"journal":false}`, w.Body.String())
+	assert.Equal(t, http.StatusServiceUnavailable, serve(s, "GET", "/health", nil).Code)
+}
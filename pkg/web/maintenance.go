@@ -0,0 +1,139 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var errPaused = errors.New("notification ingestion paused")
+
+// maintenance pauses notification ingestion, e.g. during Kafka maintenance.
+// A nil maintenance is never paused.
+type maintenance struct {
+	// start of the pause, nil if running
+	since atomic.Pointer[time.Time]
+}
+
+func (m *maintenance) pause() bool {
+	if m == nil {
+		return false
+	}
+	now := time.Now()
+	return m.since.CompareAndSwap(nil, &now)
+}
+
+func (m *maintenance) resume() bool {
+	if m == nil {
+		return false
+	}
+	return m.since.Swap(nil) != nil
+}
+
+// pausedSince returns the start of the pause, nil if running
+func (m *maintenance) pausedSince() *time.Time {
+	if m == nil {
+		return nil
+	}
+	return m.since.Load()
+}
+
+func (m *maintenance) paused() bool {
+	return m.pausedSince() != nil
+}
+
+// handleSignals pauses ingestion on SIGUSR1, resumes it on SIGUSR2 and reloads
+// the configuration on SIGHUP
+func (s Server) handleSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			switch sig {
+			case syscall.SIGUSR1:
+				s.pause(ctx, "signal")
+			case syscall.SIGUSR2:
+				s.resume(ctx, "signal")
+			case syscall.SIGHUP:
+				_ = s.reload(ctx, "signal", config.Reload)
+			}
+		}
+	}
+}
+
+func (s Server) pause(ctx context.Context, by string) {
+	if s.maintenance.pause() {
+		slog.WarnContext(ctx, "Notification ingestion paused", "by", by)
+	}
+}
+
+func (s Server) resume(ctx context.Context, by string) {
+	if s.maintenance.resume() {
+		slog.InfoContext(ctx, "Notification ingestion resumed", "by", by)
+	}
+}
+
+// rejectWhilePaused responds with 503 and Retry-After while ingestion is paused,
+// so gICS keeps the notifications and retries later
+func (s Server) rejectWhilePaused(c *gin.Context) {
+	if !s.maintenance.paused() {
+		c.Next()
+		return
+	}
+
+	retryAfter := max(s.config.App.Http.RetryAfter, time.Second)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	p := newProblem(Paused, "")
+	// rejected either way, a journal failure is reported by the health checks
+	_ = s.recordJournal(c.Request.Context(), time.Now(), nil, nil, nil, p)
+	abortWithProblem(c, p)
+}
+
+func (s Server) handleAdminPause(c *gin.Context) {
+	s.pause(c.Request.Context(), "admin:"+authUser(c.Request.Context()))
+	s.handleAdminMaintenance(c)
+}
+
+func (s Server) handleAdminResume(c *gin.Context) {
+	s.resume(c.Request.Context(), "admin:"+authUser(c.Request.Context()))
+	s.handleAdminMaintenance(c)
+}
+
+func (s Server) handleAdminMaintenance(c *gin.Context) {
+	since := s.maintenance.pausedSince()
+	c.JSON(http.StatusOK, gin.H{"paused": since != nil, "since": since})
+}
+
+// checkLiveness reports the process as alive, independent of Kafka
+func (s Server) checkLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"alive": true})
+}
+
+// checkReadiness reports whether notifications are accepted: ingestion is
+// not paused, Kafka is available and the audit journal can be written
+func (s Server) checkReadiness(c *gin.Context) {
+	paused := s.maintenance.paused()
+	healthy := s.producer.IsHealthy()
+	journal := s.journalHealthy()
+
+	status := http.StatusOK
+	if paused || !healthy || !journal {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"ready": status == http.StatusOK, "paused": paused, "healthy": healthy, "journal": journal})
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"gics-to-kafka/pkg/expiry"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.maintenance = &maintenance{}
+	s.config.App.Http.RetryAfter = 90 * time.Second
+
+	s.pause(context.Background(), "test")
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), string(Paused))
+	assert.Empty(t, p.records)
+
+	w = serve(s, "POST", "/notifications", []byte(validNotification))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	s.resume(context.Background(), "test")
+	w = serve(s, "POST", "/notification", []byte(validNotification))
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestHealthProbes(t *testing.T) {
+	s := testServer(&TestProducer{healthy: true})
+	s.maintenance = &maintenance{}
+
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/health/live", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/health/ready", nil).Code)
+
+	s.pause(context.Background(), "test")
+
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/health/live", nil).Code)
+	w := serve(s, "GET", "/health/ready", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"ready":false,"paused":true,"healthy":true,"journal":true}`, w.Body.String())
+
+	s.resume(context.Background(), "test")
+	s.producer = &TestProducer{healthy: false}
+	assert.Equal(t, http.StatusOK, serve(s, "GET", "/health/live", nil).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(s, "GET", "/health/ready", nil).Code)
+}
+
+func TestAdmin_Pause(t *testing.T) {
+	s := adminTestServer(&InspectableProducer{})
+	s.maintenance = &maintenance{}
+	post := func(endpoint string) map[string]any {
+		w := serve(s, "POST", endpoint, nil, withAuth("admin", "admin-secret"))
+		var state map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &state)
+		return state
+	}
+
+	state := post("/admin/pause")
+	assert.Equal(t, true, state["paused"])
+	assert.NotNil(t, state["since"])
+	assert.True(t, s.maintenance.paused())
+
+	state = post("/admin/resume")
+	assert.Equal(t, false, state["paused"])
+	assert.False(t, s.maintenance.paused())
+}
+
+func TestHandleSignals(t *testing.T) {
+	s := testServer(&RecordingProducer{})
+	s.maintenance = &maintenance{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.handleSignals(ctx)
+	// wait for the handler to be registered
+	time.Sleep(50 * time.Millisecond)
+
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	assert.Eventually(t, s.maintenance.paused, time.Second, 10*time.Millisecond)
+
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	assert.Eventually(t, func() bool { return !s.maintenance.paused() }, time.Second, 10*time.Millisecond)
+}
+
+func TestExpireConsent_Paused(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.maintenance = &maintenance{}
+	s.pause(context.Background(), "test")
+
+	err := s.expireConsent(context.Background(), expiry.Timer{})
+
+	assert.ErrorIs(t, err, errPaused)
+	assert.Empty(t, p.records)
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"log/slog"
+	"time"
+)
+
+func validateDateNormalization(ns []config.DateNormalization) error {
+	for _, n := range ns {
+		if n.Format != config.DateFormatOffset && n.Format != config.DateFormatUtc {
+			return fmt.Errorf("unknown date format for topic %s: %s", n.Topic, n.Format)
+		}
+	}
+	return nil
+}
+
+// dateFormat returns the date format configured for the topic or an empty
+// string to keep the original values
+func (s Server) dateFormat(topic string) string {
+	for _, n := range s.config.Kafka.NormalizeDates {
+		if n.Topic == topic {
+			return n.Format
+		}
+	}
+	return ""
+}
+
+// marshalOutput marshals v with its dates normalized as configured for the
+// topic and its identifying fields encrypted if enabled
+func (s Server) marshalOutput(ctx context.Context, v any, topic string) ([]byte, *Problem) {
+	var transforms []notification.Transform
+	if format := s.dateFormat(topic); format != "" {
+		transforms = append(transforms, notification.DateTransform(func(value string) (string, error) {
+			t, err := s.timestamps.Parse(value)
+			if err != nil {
+				return "", err
+			}
+			if format == config.DateFormatUtc {
+				t = t.UTC()
+			}
+			return t.Format(time.RFC3339Nano), nil
+		}))
+	}
+	if s.encryption != nil {
+		transforms = append(transforms, s.encryptTransform(ctx))
+	}
+
+	b, err := notification.MarshalCopy(v, transforms...)
+	if errors.Is(err, errEncryptionFailed) {
+		slog.ErrorContext(ctx, "Unable to encrypt identifying fields", "error", err)
+		return nil, newProblem(EncryptionFailed, err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to normalize dates", "error", err)
+		return nil, newProblem(InvalidTimestamp, err.Error())
+	}
+	return b, nil
+}
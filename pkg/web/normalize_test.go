@@ -0,0 +1,61 @@
+package web
+
+import (
+	"gics-to-kafka/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type NormalizeTestCase struct {
+	name     string
+	ns       []config.DateNormalization
+	expected []string
+}
+
+func TestMarshalOutput(t *testing.T) {
+	cases := []NormalizeTestCase{
+		{name: "original", expected: []string{`"consentDate":"2023-05-02 01:57:27"`, `"consentDate":"2023-05-02 01:57:27"`}},
+		{name: "offset",
+			ns: []config.DateNormalization{{Topic: "notifications", Format: config.DateFormatOffset}},
+			expected: []string{
+				`"consentDate":"2023-05-02T01:57:27+02:00"`,
+				`"consentDate":"2023-05-02 01:57:27"`,
+			}},
+		{name: "utcPerTopic",
+			ns: []config.DateNormalization{
+				{Topic: "notifications", Format: config.DateFormatOffset},
+				{Topic: "snapshots", Format: config.DateFormatUtc},
+			},
+			expected: []string{
+				`"consentDate":"2023-05-02T01:57:27+02:00"`,
+				`"consentDate":"2023-05-01T23:57:27Z"`,
+			}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &RecordingProducer{}
+			s := testServer(p)
+			s.config.Kafka.SnapshotTopic = "snapshots"
+			s.config.Kafka.NormalizeDates = c.ns
+
+			w := serve(s, "POST", "/notification", []byte(validNotification))
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			if assert.Len(t, p.records, 2) {
+				for i, e := range c.expected {
+					assert.Contains(t, string(p.records[i].Value), e)
+					if e != `"consentDate":"2023-05-02 01:57:27"` {
+						assert.Contains(t, string(p.records[i].Value), `"raw":{"consentKey.consentDate":"2023-05-02 01:57:27"}`)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDateNormalization(t *testing.T) {
+	assert.NoError(t, validateDateNormalization([]config.DateNormalization{{Topic: "test", Format: config.DateFormatUtc}}))
+	assert.Error(t, validateDateNormalization([]config.DateNormalization{{Topic: "test", Format: "iso"}}))
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"gics-to-kafka/pkg/policy"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type SignerRef struct {
+	IdType string `json:"idType"`
+	Id     string `json:"id"`
+}
+
+type PolicyCheckRequest struct {
+	SignerId  *SignerRef      `json:"signerId"`
+	SignerIds []SignerRef     `json:"signerIds"`
+	Policies  []policy.Policy `json:"policies"`
+	Date      string          `json:"date"`
+}
+
+type PolicyCheckResult struct {
+	SignerId SignerRef       `json:"signerId"`
+	Policies []policy.Result `json:"policies"`
+}
+
+type PolicyCheckResponse struct {
+	Date    string              `json:"date"`
+	Results []PolicyCheckResult `json:"results"`
+}
+
+func (s Server) handlePolicyCheck(c *gin.Context) {
+	var req PolicyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to bind JSON", "error", err)
+		abortWithProblem(c, newProblem(InvalidJson, err.Error()))
+		return
+	}
+
+	signers := req.SignerIds
+	if req.SignerId != nil {
+		signers = append([]SignerRef{*req.SignerId}, signers...)
+	}
+	if len(signers) == 0 {
+		abortWithProblem(c, newProblem(InvalidRequest, "signerId or signerIds required"))
+		return
+	}
+	if len(req.Policies) == 0 {
+		abortWithProblem(c, newProblem(InvalidRequest, "policies required"))
+		return
+	}
+	for _, p := range req.Policies {
+		if p.Name == "" {
+			abortWithProblem(c, newProblem(InvalidRequest, "policy name required"))
+			return
+		}
+	}
+
+	// consent dates are local to the configured zone
+	date, err := policy.ParseReferenceDate(req.Date, time.Now().In(s.timestamps.Location()))
+	if err != nil {
+		abortWithProblem(c, newProblem(InvalidRequest, err.Error()))
+		return
+	}
+
+	res := PolicyCheckResponse{Date: policy.FormatDate(date), Results: make([]PolicyCheckResult, len(signers))}
+	for i, signer := range signers {
+		consents, err := s.store.Consents(signer.IdType, signer.Id)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to read consents from store", "error", err)
+			abortWithProblem(c, newProblem(InternalError, "Failed to read consents"))
+			return
+		}
+
+		res.Results[i] = PolicyCheckResult{
+			SignerId: signer,
+			Policies: policy.Check(consents, req.Policies, date, s.timestamps.Parse),
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"encoding/json"
+	"gics-to-kafka/pkg/policy"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const policyNotification = `{"type":"GICS.AddConsent","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"consentKey\":{\"consentTemplateKey\":{\"domainName\":\"MII\",\"name\":\"Patienteneinwilligung MII\",\"version\":\"1.6.d\"},\"signerIds\":[{\"idType\":\"Patienten-ID\",\"id\":\"1\",\"orderNumber\":1}],\"consentDate\":\"2023-05-02 01:57:27\"},\"currentPolicyStates\":[{\"key\":{\"domainName\":\"MII\",\"name\":\"MDAT_erheben\",\"version\":\"1.1\"},\"value\":true},{\"key\":{\"domainName\":\"MII\",\"name\":\"IDAT_erheben\",\"version\":\"1.0\"},\"value\":false}]}"}`
+
+type PolicyCheckTestCase struct {
+	name       string
+	body       string
+	statusCode int
+	expected   [][]policy.Decision
+}
+
+func TestHandlePolicyCheck(t *testing.T) {
+	st, _ := store.Open(filepath.Join(t.TempDir(), "consents.db"), timestamp.Default.Parse)
+	t.Cleanup(func() { _ = st.Close() })
+	s := testServer(&RecordingProducer{})
+	s.store = st
+
+	w := serve(s, "POST", "/notification", []byte(policyNotification))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	cases := []PolicyCheckTestCase{
+		{
+			name: "singleSigner",
+			body: `{"signerId":{"idType":"Patienten-ID","id":"1"},"date":"2024-01-01",
+				"policies":[{"name":"MDAT_erheben"},{"domainName":"MII","name":"IDAT_erheben","version":"1.0"},{"name":"foo"}]}`,
+			statusCode: http.StatusOK,
+			expected:   [][]policy.Decision{{policy.Permit, policy.Deny, policy.Unknown}},
+		},
+		{
+			name: "batch",
+			body: `{"signerId":{"idType":"Patienten-ID","id":"1"},"signerIds":[{"idType":"Patienten-ID","id":"2"}],
+				"policies":[{"name":"MDAT_erheben"}]}`,
+			statusCode: http.StatusOK,
+			expected:   [][]policy.Decision{{policy.Permit}, {policy.Unknown}},
+		},
+		{
+			name:       "beforeConsentDate",
+			body:       `{"signerIds":[{"idType":"Patienten-ID","id":"1"}],"date":"2023-05-01","policies":[{"name":"MDAT_erheben"}]}`,
+			statusCode: http.StatusOK,
+			expected:   [][]policy.Decision{{policy.Unknown}},
+		},
+		{name: "missingSigner", body: `{"policies":[{"name":"MDAT_erheben"}]}`, statusCode: http.StatusBadRequest},
+		{name: "missingPolicies", body: `{"signerId":{"idType":"Patienten-ID","id":"1"}}`, statusCode: http.StatusBadRequest},
+		{name: "missingPolicyName", body: `{"signerId":{"idType":"Patienten-ID","id":"1"},"policies":[{"version":"1.0"}]}`,
+			statusCode: http.StatusBadRequest},
+		{name: "invalidDate", body: `{"signerId":{"idType":"Patienten-ID","id":"1"},"date":"01.01.2024","policies":[{"name":"MDAT_erheben"}]}`,
+			statusCode: http.StatusBadRequest},
+		{name: "invalidJson", body: `test`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := serve(s, "POST", "/policy-check", []byte(c.body))
+
+			assert.Equal(t, c.statusCode, w.Code)
+			if c.expected == nil {
+				return
+			}
+
+			var actual PolicyCheckResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &actual)
+			if assert.Len(t, actual.Results, len(c.expected)) {
+				for i, decisions := range c.expected {
+					for j, d := range decisions {
+						assert.Equal(t, d, actual.Results[i].Policies[j].Decision)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePolicyCheck_StoreError(t *testing.T) {
+	s := testServer(&RecordingProducer{})
+	s.store = FailingStore{}
+
+	w := serve(s, "POST", "/policy-check", []byte(`{"signerId":{"idType":"a","id":"1"},"policies":[{"name":"b"}]}`))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandlePolicyCheck_DefaultDate(t *testing.T) {
+	st, _ := store.Open(filepath.Join(t.TempDir(), "consents.db"), timestamp.Default.Parse)
+	t.Cleanup(func() { _ = st.Close() })
+	s := testServer(&RecordingProducer{})
+	s.store = st
+	// far from the local zone, so the date differs for half of the day
+	s.timestamps, _ = timestamp.NewParser("Pacific/Kiritimati", nil)
+
+	w := serve(s, "POST", "/policy-check", []byte(`{"signerId":{"idType":"a","id":"1"},"policies":[{"name":"b"}]}`))
+
+	var actual PolicyCheckResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &actual)
+	date, err := time.ParseInLocation("2006-01-02 15:04:05", actual.Date, s.timestamps.Location())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), date, 5*time.Second)
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	problemContentType   = "application/problem+json"
+	problemTypeNamespace = "urn:gics-to-kafka:problem:"
+)
+
+type ErrorCode string
+
+const (
+	InvalidJson         ErrorCode = "INVALID_JSON"
+	InvalidBatch        ErrorCode = "INVALID_BATCH"
+	IncompleteData      ErrorCode = "INCOMPLETE_NOTIFICATION"
+	InvalidClientId     ErrorCode = "INVALID_CLIENT_ID"
+	InvalidData         ErrorCode = "INVALID_DATA"
+	InvalidTimestamp    ErrorCode = "INVALID_TIMESTAMP"
+	UnknownType         ErrorCode = "UNKNOWN_NOTIFICATION_TYPE"
+	MissingSignerId     ErrorCode = "MISSING_SIGNER_ID"
+	InvalidRequest      ErrorCode = "INVALID_REQUEST"
+	Unauthorized        ErrorCode = "UNAUTHORIZED"
+	ClientNotAllowed    ErrorCode = "CLIENT_NOT_ALLOWED"
+	NotFound            ErrorCode = "NOT_FOUND"
+	MethodNotAllowed    ErrorCode = "METHOD_NOT_ALLOWED"
+	KafkaUnavailable    ErrorCode = "KAFKA_UNAVAILABLE"
+	KafkaDeliveryFailed ErrorCode = "KAFKA_DELIVERY_FAILED"
+	EncryptionFailed    ErrorCode = "ENCRYPTION_FAILED"
+	Paused              ErrorCode = "SERVICE_PAUSED"
+	JournalUnavailable  ErrorCode = "JOURNAL_UNAVAILABLE"
+	InternalError       ErrorCode = "INTERNAL_ERROR"
+)
+
+type errorDefinition struct {
+	status int
+	title  string
+}
+
+var errorDefinitions = map[ErrorCode]errorDefinition{
+	InvalidJson:         {http.StatusBadRequest, "Invalid JSON"},
+	InvalidBatch:        {http.StatusBadRequest, "Invalid notification batch"},
+	IncompleteData:      {http.StatusBadRequest, "Incomplete notification data"},
+	InvalidClientId:     {http.StatusBadRequest, "Invalid or missing clientId"},
+	InvalidData:         {http.StatusBadRequest, "Failed to parse notification data"},
+	InvalidTimestamp:    {http.StatusBadRequest, "Failed to parse timestamp"},
+	UnknownType:         {http.StatusBadRequest, "Unsupported notification type"},
+	MissingSignerId:     {http.StatusBadRequest, "Failed to parse signerId"},
+	InvalidRequest:      {http.StatusBadRequest, "Invalid request"},
+	Unauthorized:        {http.StatusUnauthorized, "Authorization required"},
+	ClientNotAllowed:    {http.StatusForbidden, "Client not allowed for user"},
+	NotFound:            {http.StatusNotFound, "Resource not found"},
+	MethodNotAllowed:    {http.StatusMethodNotAllowed, "Method not allowed"},
+	KafkaUnavailable:    {http.StatusServiceUnavailable, "Failed to send notification to Kafka"},
+	KafkaDeliveryFailed: {http.StatusBadGateway, "Failed to save message to Kafka topic"},
+	EncryptionFailed:    {http.StatusInternalServerError, "Failed to encrypt notification data"},
+	Paused:              {http.StatusServiceUnavailable, "Notification ingestion paused"},
+	JournalUnavailable:  {http.StatusServiceUnavailable, "Failed to write audit journal"},
+	InternalError:       {http.StatusInternalServerError, "Internal server error"},
+}
+
+// Problem is an RFC 7807 problem details error response
+type Problem struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Status    int       `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	Instance  string    `json:"instance,omitempty"`
+	Code      ErrorCode `json:"code"`
+	RequestId string    `json:"requestId,omitempty"`
+}
+
+func newProblem(code ErrorCode, detail string) *Problem {
+	def, ok := errorDefinitions[code]
+	if !ok {
+		code = InternalError
+		def = errorDefinitions[InternalError]
+	}
+
+	return &Problem{
+		Type:   problemTypeNamespace + strings.ToLower(string(code)),
+		Title:  def.title,
+		Status: def.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+func abortWithProblem(c *gin.Context, p *Problem) {
+	p.Instance = c.Request.URL.Path
+	p.RequestId = requestId(c)
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+func recoverWithProblem(c *gin.Context, err any) {
+	slog.ErrorContext(c.Request.Context(), "Recovered from panic", "error", err)
+	abortWithProblem(c, newProblem(InternalError, ""))
+}
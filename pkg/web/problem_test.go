@@ -0,0 +1,99 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewProblem(t *testing.T) {
+	p := newProblem(MissingSignerId, "test")
+
+	assert.Equal(t, "urn:gics-to-kafka:problem:missing_signer_id", p.Type)
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, MissingSignerId, p.Code)
+	assert.Equal(t, "Failed to parse signerId: test", p.Error())
+}
+
+func TestNewProblem_UnknownCode(t *testing.T) {
+	p := newProblem("FOO", "")
+
+	assert.Equal(t, InternalError, p.Code)
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, "Internal server error", p.Error())
+}
+
+type ProblemTestCase struct {
+	name       string
+	method     string
+	endpoint   string
+	user       string
+	body       string
+	requestId  string
+	statusCode int
+	code       ErrorCode
+}
+
+func TestErrorResponses(t *testing.T) {
+	cases := []ProblemTestCase{
+		{name: "invalidJson", method: "POST", endpoint: "/notification", user: "test", body: "test",
+			statusCode: http.StatusBadRequest, code: InvalidJson},
+		{name: "invalidClientId", method: "POST", endpoint: "/notification", user: "test",
+			body:       `{"type":"GICS.AddConsent","clientId":"test","createdAt":"2023-06-05T12:09:10","data":"{}"}`,
+			requestId:  "4711",
+			statusCode: http.StatusBadRequest, code: InvalidClientId},
+		{name: "unauthorized", method: "POST", endpoint: "/notification", user: "foo", body: "{}",
+			statusCode: http.StatusUnauthorized, code: Unauthorized},
+		{name: "notFound", method: "GET", endpoint: "/foo",
+			statusCode: http.StatusNotFound, code: NotFound},
+		{name: "methodNotAllowed", method: "GET", endpoint: "/notification",
+			statusCode: http.StatusMethodNotAllowed, code: MethodNotAllowed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := testServer(nil)
+			opts := []func(*http.Request){withAuth(c.user, "test")}
+			if c.requestId != "" {
+				opts = append(opts, withHeader(RequestIdHeader, c.requestId))
+			}
+			w := serve(s, c.method, c.endpoint, []byte(c.body), opts...)
+
+			var p Problem
+			_ = json.Unmarshal(w.Body.Bytes(), &p)
+
+			assert.Equal(t, c.statusCode, w.Code)
+			assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, c.code, p.Code)
+			assert.Equal(t, c.statusCode, p.Status)
+			assert.Equal(t, c.endpoint, p.Instance)
+			assert.NotEmpty(t, p.RequestId)
+			assert.Equal(t, w.Header().Get(RequestIdHeader), p.RequestId)
+			if c.requestId != "" {
+				assert.Equal(t, c.requestId, p.RequestId)
+			}
+		})
+	}
+}
+
+func TestRecoverWithProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestIdMiddleware(), gin.CustomRecovery(recoverWithProblem))
+	r.GET("/panic", func(_ *gin.Context) {
+		panic("test")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var p Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &p)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, InternalError, p.Code)
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gics-to-kafka/pkg/client"
+	"gics-to-kafka/pkg/config"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// settings can be reloaded at runtime without restart
+type settings struct {
+	accounts      gin.Accounts
+	adminAccounts gin.Accounts
+	clients       *client.Registry
+	outputTopic   string
+	epixTopic     string
+	gpasTopic     string
+}
+
+// newSettings validates the reloadable part of the configuration
+func newSettings(c config.AppConfig) (*settings, error) {
+	clients, err := client.NewRegistry(c.App.Clients, c.App.OutputFormat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client configuration: %w", err)
+	}
+	if a := c.App.Http.Admin; a.Enabled && (a.Auth.User == "" || a.Auth.Password == "") {
+		return nil, errors.New("invalid admin configuration: admin user and password are required")
+	}
+	if err = validateEncryption(c); err != nil {
+		return nil, fmt.Errorf("invalid encryption configuration: %w", err)
+	}
+	users := accounts(c.App.Http.Auth)
+	if len(users) == 0 {
+		return nil, errors.New("invalid auth configuration: at least one account with user and password is required")
+	}
+
+	return &settings{
+		accounts:      users,
+		adminAccounts: accounts(c.App.Http.Admin.Auth),
+		clients:       clients,
+		outputTopic:   c.Kafka.OutputTopic,
+		epixTopic:     c.Kafka.EpixTopic,
+		gpasTopic:     c.Kafka.GpasTopic,
+	}, nil
+}
+
+// liveConfig holds the applied configuration and its reloadable settings
+type liveConfig struct {
+	// serializes reloads
+	mu       sync.Mutex
+	config   config.AppConfig
+	settings atomic.Pointer[settings]
+}
+
+func newLiveConfig(c config.AppConfig, st *settings) *liveConfig {
+	l := &liveConfig{config: c}
+	l.settings.Store(st)
+	return l
+}
+
+// current returns the reloadable settings, taken from the startup configuration
+// validated by NewServer if reloading is not set up
+func (s Server) current() *settings {
+	if s.live != nil {
+		return s.live.settings.Load()
+	}
+	st, err := newSettings(s.config)
+	if err != nil {
+		// no accounts, all requests are rejected
+		return &settings{}
+	}
+	return st
+}
+
+func (s Server) userAccounts() gin.Accounts {
+	return s.current().accounts
+}
+
+func (s Server) adminAccounts() gin.Accounts {
+	return s.current().adminAccounts
+}
+
+// appliedConfig returns the configuration in effect, including reloaded settings
+func (s Server) appliedConfig() config.AppConfig {
+	if s.live == nil {
+		return s.config
+	}
+	s.live.mu.Lock()
+	defer s.live.mu.Unlock()
+	return s.live.config
+}
+
+// reloadableKeys are the configuration properties applied by reload
+var reloadableKeys = []struct {
+	name  string
+	field func(c *config.AppConfig) any
+}{
+	{"app.log-level", func(c *config.AppConfig) any { return &c.App.LogLevel }},
+	{"app.http.auth", func(c *config.AppConfig) any { return &c.App.Http.Auth }},
+	{"app.http.admin.auth", func(c *config.AppConfig) any { return &c.App.Http.Admin.Auth }},
+	{"app.clients", func(c *config.AppConfig) any { return &c.App.Clients }},
+	{"app.output-format", func(c *config.AppConfig) any { return &c.App.OutputFormat }},
+	{"kafka.output-topic", func(c *config.AppConfig) any { return &c.Kafka.OutputTopic }},
+	{"kafka.epix-topic", func(c *config.AppConfig) any { return &c.Kafka.EpixTopic }},
+	{"kafka.gpas-topic", func(c *config.AppConfig) any { return &c.Kafka.GpasTopic }},
+}
+
+// reload validates the loaded configuration and applies its reloadable settings.
+// The current settings are kept if loading or validation fails.
+func (s Server) reload(ctx context.Context, source string, load func() (*config.AppConfig, error)) error {
+	s.live.mu.Lock()
+	defer s.live.mu.Unlock()
+
+	c, err := load()
+	if err == nil && c == nil {
+		err = errors.New("empty configuration")
+	}
+	var st *settings
+	if err == nil {
+		err = errors.Join(validateLogLevel(c.App.LogLevel), validateExpiry(c.Expiry), validateUnknownTypes(c.App.UnknownTypes),
+			validateBatch(c.App.Http.Batch))
+	}
+	if err == nil {
+		st, err = newSettings(*c)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Configuration reload failed, keeping current configuration", "source", source, "error", err)
+		return err
+	}
+
+	// settings which require a restart keep their current value
+	applied := s.live.config
+	var changes []string
+	for _, k := range reloadableKeys {
+		if !reflect.DeepEqual(k.field(&applied), k.field(c)) {
+			changes = append(changes, k.name)
+		}
+		reflect.ValueOf(k.field(&applied)).Elem().Set(reflect.ValueOf(k.field(c)).Elem())
+	}
+	restart := !reflect.DeepEqual(applied, *c)
+
+	s.live.config = applied
+	s.live.settings.Store(st)
+	_ = config.SetLogLevel(c.App.LogLevel)
+
+	slog.InfoContext(ctx, "Configuration reloaded", "source", source, "changes", changes)
+	if restart {
+		slog.WarnContext(ctx, "Configuration changes require a restart to take effect", "source", source)
+	}
+	return nil
+}
+
+func validateLogLevel(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"gics-to-kafka/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func reloadTestServer(p *RecordingProducer) Server {
+	return reloadable(testServer(p))
+}
+
+// reloadable sets up reloading the server's configuration
+func reloadable(s Server) Server {
+	s.config.App.LogLevel = "info"
+	s.config.App.Http.Batch = config.Batch{Concurrency: 50, MaxSize: 1000, MaxBodySize: 10}
+	st, _ := newSettings(s.config)
+	s.live = newLiveConfig(s.config, st)
+	return s
+}
+
+func TestReload(t *testing.T) {
+	p := &RecordingProducer{}
+	s := reloadTestServer(p)
+
+	c := s.config
+	c.App.LogLevel = "debug"
+	c.App.Http.Auth = config.Auth{User: "test", Password: "changed"}
+	c.Kafka.OutputTopic = "routed"
+	err := s.reload(context.Background(), "test", func() (*config.AppConfig, error) { return &c, nil })
+	t.Cleanup(func() { _ = config.SetLogLevel("info") })
+
+	assert.NoError(t, err)
+	assert.Equal(t, "routed", s.live.config.Kafka.OutputTopic)
+	assert.Equal(t, http.StatusUnauthorized, serve(s, "POST", "/notification", []byte(validNotification)).Code)
+
+	w := serve(s, "POST", "/notification", []byte(validNotification), withAuth("test", "changed"))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 1) {
+		assert.Equal(t, "routed", p.records[0].Topic)
+	}
+}
+
+func TestReload_KeepsCurrentOnError(t *testing.T) {
+	cases := []struct {
+		name string
+		load func(c config.AppConfig) (*config.AppConfig, error)
+	}{
+		{"load error", func(config.AppConfig) (*config.AppConfig, error) { return nil, errors.New("parse error") }},
+		{"log level", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.LogLevel = "verbose"
+			return &c, nil
+		}},
+		{"clients", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.Clients = []config.Client{{Pattern: "["}}
+			return &c, nil
+		}},
+		{"auth", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.Http.Auth = config.Auth{}
+			return &c, nil
+		}},
+		{"expiry", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.Expiry = config.Expiry{Enabled: true, Path: "expiry.db"}
+			return &c, nil
+		}},
+		{"unknown types", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.UnknownTypes = "drop"
+			return &c, nil
+		}},
+		{"batch", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.Http.Batch.Concurrency = 0
+			return &c, nil
+		}},
+		{"admin", func(c config.AppConfig) (*config.AppConfig, error) {
+			c.App.Http.Admin = config.Admin{Enabled: true}
+			return &c, nil
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := reloadTestServer(&RecordingProducer{})
+			current := s.current()
+
+			err := s.reload(context.Background(), "test", func() (*config.AppConfig, error) {
+				c := s.config
+				c.App.Http.Auth.Password = "changed"
+				return tc.load(c)
+			})
+
+			assert.Error(t, err)
+			assert.Same(t, current, s.current())
+			assert.Equal(t, http.StatusCreated, serve(s, "POST", "/notification", []byte(validNotification)).Code)
+		})
+	}
+}
+
+func TestReload_RestartRequired(t *testing.T) {
+	s := reloadTestServer(&RecordingProducer{})
+
+	c := s.config
+	c.App.Http.Port = "9090"
+	err := s.reload(context.Background(), "test", func() (*config.AppConfig, error) { return &c, nil })
+
+	assert.NoError(t, err)
+	// not applied until restart
+	assert.Equal(t, "", s.live.config.App.Http.Port)
+}
+
+func TestCurrent_RejectsEmptyCredentials(t *testing.T) {
+	s := testServer(&RecordingProducer{})
+	s.config.App.Http.Auth = config.Auth{}
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
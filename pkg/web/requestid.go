@@ -0,0 +1,29 @@
+package web
+
+import (
+	"gics-to-kafka/pkg/correlation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIdHeader = correlation.Header
+
+// requestIdMiddleware accepts the client's request id or generates a new one,
+// adds it to the request context and returns it with the response
+func requestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIdHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+			c.Request.Header.Set(RequestIdHeader, id)
+		}
+
+		c.Request = c.Request.WithContext(correlation.NewContext(c.Request.Context(), id))
+		c.Header(RequestIdHeader, id)
+		c.Next()
+	}
+}
+
+func requestId(c *gin.Context) string {
+	return correlation.FromContext(c.Request.Context())
+}
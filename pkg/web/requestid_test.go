@@ -0,0 +1,51 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/correlation"
+	"gics-to-kafka/pkg/kafka"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type ContextProducer struct {
+	TestProducer
+	requestIds chan string
+}
+
+func (p ContextProducer) Send(ctx context.Context, _ kafka.Record, deliveryChan chan cKafka.Event) {
+	p.requestIds <- correlation.FromContext(ctx)
+	deliveryChan <- &cKafka.Message{}
+}
+
+func TestRequestIdMiddleware(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{name: "providedId", header: "4711"},
+		{name: "generatedId"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := ContextProducer{requestIds: make(chan string, 1)}
+			s := testServer(p)
+			var opts []func(*http.Request)
+			if c.header != "" {
+				opts = append(opts, withHeader(RequestIdHeader, c.header))
+			}
+			w := serve(s, "POST", "/notification", []byte(validNotification), opts...)
+
+			actual := w.Header().Get(RequestIdHeader)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.NotEmpty(t, actual)
+			if c.header != "" {
+				assert.Equal(t, c.header, actual)
+			}
+			assert.Equal(t, actual, <-p.requestIds)
+		})
+	}
+}
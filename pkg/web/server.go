@@ -1,80 +1,67 @@
 package web
 
 import (
-	"crypto"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"gics-to-kafka/pkg/client"
 	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/envelope"
+	"gics-to-kafka/pkg/expiry"
+	"gics-to-kafka/pkg/journal"
 	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/template"
+	"gics-to-kafka/pkg/timestamp"
 	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	sloggin "github.com/samber/slog-gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"net/http"
-	"sort"
-	"strings"
+	"os"
 	"time"
 )
 
-type Notification struct {
-	ClientId  *string `bson:"clientId" json:"clientId"`
-	Type      *string `bson:"type" json:"type"`
-	CreatedAt *string `bson:"createdAt" json:"createdAt"`
-	Data      *string `bson:"data" json:"data"`
-}
-
-type PolicyState struct {
-	Key   *PolicyStateKey `bson:"key" json:"key"`
-	Value bool            `bson:"value" json:"value"`
-}
-
-type PolicyStateKey struct {
-	DomainName *string `bson:"domainName" json:"domainName"`
-	Name       *string `bson:"name" json:"name"`
-	Version    *string `bson:"version" json:"version"`
-}
-
-type Context struct {
-	Qc struct {
-		QcPassed  bool   `bson:"qcPassed" json:"qcPassed"`
-		Type      string `bson:"type json:type"`
-		Inspector string `bson:"inspector json:inspector"`
-		Comment   string `bson:"comment" json:"comment"`
-	} `bson:"qc" json:"qc"`
-}
-
-type NotificationData struct {
-	Context              *Context      `bson:"context" json:"context"`
-	ConsentKey           *ConsentKey   `bson:"consentKey" json:"consentKey"`
-	PreviousPolicyStates []PolicyState `bson:"previousPolicyStates" json:"previousPolicyStates"`
-	CurrentPolicyStates  []PolicyState `bson:"currentPolicyStates" json:"currentPolicyStates"`
-}
-type ConsentKey struct {
-	ConsentTemplateKey *ConsentTemplateKey `bson:"consentTemplateKey" json:"consentTemplateKey"`
-	SignerIds          []SignerId          `bson:"signerIds" json:"signerIds"`
-	ConsentDate        *string             `bson:"consentDate" json:"consentDate"`
-}
-
-type ConsentTemplateKey struct {
-	DomainName *string `bson:"domainName" json:"domainName"`
-	Name       *string `bson:"name" json:"name"`
-	Version    *string `bson:"version" json:"version"`
-}
-
-type SignerId struct {
-	IdType      string `bson:"idType" json:"idType"`
-	Id          string `bson:"id" json:"id"`
-	OrderNumber int    `bson:"orderNumber" json:"orderNumber"`
-}
-
 type Server struct {
 	config   config.AppConfig
 	producer kafka.Producer
+	store    store.ConsentStore
+	// reloadable settings, taken from config if nil
+	live *liveConfig
+	// parses notification timestamps, defaults if nil
+	timestamps *timestamp.Parser
+	// consent template metadata, no enrichment if nil
+	templates *template.Cache
+	// encrypts identifying fields, no encryption if nil
+	encryption envelope.KMS
+	// consent expiry timers, no expiration if nil
+	expiry *expiry.Scheduler
+	// audit journal of received notifications, not recorded if nil
+	journal *journal.Journal
+	// runtime state for the admin API, not tracked if nil
+	monitor *monitor
+	// pauses notification ingestion, never paused if nil
+	maintenance *maintenance
 }
 
 func (s Server) Run() {
 	r := s.setupRouter()
 
+	if s.expiry != nil {
+		go s.expiry.Run(context.Background(), s.config.Expiry.Interval, s.expireConsent)
+	}
+	go s.handleSignals(context.Background())
+	config.Watch(func() {
+		_ = s.reload(context.Background(), "file", config.Reload)
+	})
+
 	slog.Info("Starting server", "port", s.config.App.Http.Port)
 	for _, v := range r.Routes() {
 		slog.Info("Route configured", "path", v.Path, "method", v.Method)
@@ -86,117 +73,384 @@ func (s Server) Run() {
 func (s Server) setupRouter() *gin.Engine {
 	r := gin.New()
 	_ = r.SetTrustedProxies(nil)
-	r.Use(sloggin.New(slog.Default()), gin.Recovery())
+	r.HandleMethodNotAllowed = true
+	r.Use(
+		requestIdMiddleware(),
+		sloggin.NewWithConfig(slog.New(newAccessLogHandler(slog.Default().Handler())), sloggin.Config{
+			DefaultLevel:     slog.LevelInfo,
+			ClientErrorLevel: slog.LevelWarn,
+			ServerErrorLevel: slog.LevelError,
+		}),
+		gin.CustomRecovery(recoverWithProblem),
+	)
+	r.NoRoute(func(c *gin.Context) {
+		abortWithProblem(c, newProblem(NotFound, ""))
+	})
+	r.NoMethod(func(c *gin.Context) {
+		abortWithProblem(c, newProblem(MethodNotAllowed, ""))
+	})
 
-	r.POST("/notification", gin.BasicAuth(gin.Accounts{
-		s.config.App.Http.Auth.User: s.config.App.Http.Auth.Password,
-	}), s.handleNotification)
+	notifications := r.Group("/", otelgin.Middleware(s.config.App.Name), basicAuth(s.userAccounts), s.rejectWhilePaused)
+	notifications.POST("/notification", s.handleNotification)
+	notifications.POST("/notifications", s.handleNotifications)
+	if s.store != nil {
+		r.GET(consentsRoute, basicAuth(s.userAccounts), s.handleConsents)
+		r.POST("/policy-check", basicAuth(s.userAccounts), s.handlePolicyCheck)
+	}
 	r.GET("/health", s.checkHealth)
+	r.GET("/health/live", s.checkLiveness)
+	r.GET("/health/ready", s.checkReadiness)
+	if s.config.App.Http.Admin.Enabled {
+		s.setupAdminRoutes(r)
+	}
 
 	return r
 }
 
 func NewServer(config config.AppConfig) *Server {
-	return &Server{config: config, producer: kafka.NewProducer(config.Kafka)}
+	settings, err := newSettings(config)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	timestamps, err := newTimestampParser(config.App.Time)
+	if err != nil {
+		slog.Error("Invalid time configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if err = validateDateNormalization(config.Kafka.NormalizeDates); err != nil {
+		slog.Error("Invalid date normalization configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if err = validateExpiry(config.Expiry); err != nil {
+		slog.Error("Invalid expiry configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if err = validateUnknownTypes(config.App.UnknownTypes); err != nil {
+		slog.Error("Invalid unknown types configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if err = validateBatch(config.App.Http.Batch); err != nil {
+		slog.Error("Invalid batch configuration", "error", err)
+		os.Exit(1)
+	}
+
+	s := &Server{config: config, producer: kafka.NewProducer(config.Kafka), live: newLiveConfig(config, settings), timestamps: timestamps,
+		monitor: newMonitor(config.App.Http.Admin.Failures), maintenance: &maintenance{}}
+	if config.Kafka.Encryption.Enabled {
+		s.encryption = newKMS(config.Kafka.Encryption)
+	}
+	if config.Store.Enabled {
+		s.store = newStore(config, s.encryption, timestamps)
+	}
+	if config.Enrichment.Enabled {
+		s.templates = template.NewCache(template.NewSoapResolver(config.Gics), config.Enrichment.CacheTtl)
+	}
+	if config.Expiry.Enabled {
+		s.expiry = newScheduler(config)
+	}
+	if config.Journal.Enabled {
+		s.journal = newJournal(config.Journal)
+	}
+	return s
+}
+
+// Validate checks the configuration like NewServer without connecting to Kafka
+func Validate(c config.AppConfig) error {
+	_, err := newSettings(c)
+	_, timeErr := newTimestampParser(c.App.Time)
+	if timeErr != nil {
+		timeErr = fmt.Errorf("invalid time configuration: %w", timeErr)
+	}
+	dateErr := validateDateNormalization(c.Kafka.NormalizeDates)
+	if dateErr != nil {
+		dateErr = fmt.Errorf("invalid date normalization configuration: %w", dateErr)
+	}
+	expiryErr := validateExpiry(c.Expiry)
+	if expiryErr != nil {
+		expiryErr = fmt.Errorf("invalid expiry configuration: %w", expiryErr)
+	}
+	unknownErr := validateUnknownTypes(c.App.UnknownTypes)
+	if unknownErr != nil {
+		unknownErr = fmt.Errorf("invalid unknown types configuration: %w", unknownErr)
+	}
+	batchErr := validateBatch(c.App.Http.Batch)
+	if batchErr != nil {
+		batchErr = fmt.Errorf("invalid batch configuration: %w", batchErr)
+	}
+	return errors.Join(err, timeErr, dateErr, expiryErr, unknownErr, batchErr)
+}
+
+func newTimestampParser(c config.Time) (*timestamp.Parser, error) {
+	switch c.RecordTimestamp {
+	case "", config.RecordTimestampCreatedAt, config.RecordTimestampConsentDate, config.RecordTimestampReceiveTime:
+	default:
+		return nil, fmt.Errorf("unknown record timestamp source: %s", c.RecordTimestamp)
+	}
+	return timestamp.NewParser(c.Zone, c.Layouts)
 }
 
 func (s Server) handleNotification(c *gin.Context) {
+	received := time.Now()
+
+	// bind to struct, keeping the body as received for the audit journal
+	var n notification.Notification
+	_, span := tracer().Start(c.Request.Context(), "bind notification")
+	body, err := c.GetRawData()
+	if err == nil {
+		err = binding.JSON.BindBody(body, &n)
+	}
+	endSpan(span, err)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to bind JSON", "error", err)
+		p := s.recordOutcome(c.Request.Context(), received, body, nil, nil, newProblem(InvalidJson, err.Error()))
+		abortWithProblem(c, p)
+		return
+	}
 
-	// bind to struct
-	var n Notification
-	if err := c.ShouldBindJSON(&n); err != nil {
-		slog.Error("Failed to bind JSON", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+	tp, p := s.processNotification(c.Request.Context(), n, received)
+	p = s.recordOutcome(c.Request.Context(), received, body, &n, tp, p)
+	if p != nil {
+		abortWithProblem(c, p)
 		return
 	}
+	c.Status(http.StatusCreated)
+}
 
+func (s Server) processNotification(ctx context.Context, n notification.Notification, received time.Time) (*cKafka.TopicPartition, *Problem) {
 	if n.ClientId == nil || n.Type == nil || n.Data == nil || n.CreatedAt == nil {
-		slog.Error("Incomplete notification received")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Incomplete notification data"})
-		return
+		slog.ErrorContext(ctx, "Incomplete notification received")
+		return nil, newProblem(IncompleteData, "clientId, type, createdAt and data are required")
 	}
 
-	slog.Debug("Notification received", "clientId", *n.ClientId, "type", *n.Type, "createdAt", *n.CreatedAt)
+	slog.DebugContext(ctx, "Notification received", "clientId", *n.ClientId, "type", *n.Type, "createdAt", *n.CreatedAt)
 
-	if !strings.Contains(*n.ClientId, "gICS_") {
-		slog.Error("Invalid 'clientId' property. Should be prefixed with: 'gICS_'")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid or missing clientId"})
-		return
+	cl, err := s.current().clients.Match(*n.ClientId, authUser(ctx))
+	if errors.Is(err, client.ErrForbidden) {
+		slog.ErrorContext(ctx, "Client not allowed for user", "clientId", *n.ClientId, "user", authUser(ctx))
+		return nil, newProblem(ClientNotAllowed, *n.ClientId)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid 'clientId' property", "clientId", *n.ClientId)
+		return nil, newProblem(InvalidClientId, *n.ClientId)
 	}
+	src := cl.Source
 
-	var d NotificationData
-	if err := json.Unmarshal([]byte(*n.Data), &d); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse request body"})
-		return
+	created, err := s.timestamps.Parse(*n.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to parse created date", "error", err)
+		return nil, newProblem(InvalidTimestamp, err.Error())
 	}
 
-	// get signer id
-	signerId := d.SignerId()
-	if signerId == nil {
-		slog.Error("Request ist missing signerId type")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse signerId",
-		})
-		return
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("gics.source", src.Name),
+		attribute.String("gics.client_id", *n.ClientId),
+		attribute.String("gics.notification_type", *n.Type),
+	)
+
+	_, span := tracer().Start(ctx, "parse notification data")
+	payload, err := src.Parse(*n.Type, []byte(*n.Data))
+	endSpan(span, err)
+	if errors.Is(err, notification.ErrUnknownType) {
+		ts, _ := s.recordTimestamp(created, received, nil)
+		return s.processUnknownType(ctx, n, cl, ts)
+	}
+	if errors.Is(err, notification.ErrMissingSignerId) {
+		slog.ErrorContext(ctx, "Request ist missing signerId type")
+		return nil, newProblem(MissingSignerId, err.Error())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse request body", "error", err)
+		return nil, newProblem(InvalidData, err.Error())
 	}
 
-	listener := make(chan cKafka.Event, 1)
-	s.sendNotification(signerId, n.CreatedAt, d, listener)
+	ts, err := s.recordTimestamp(created, received, payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to parse record timestamp", "error", err)
+		return nil, newProblem(InvalidTimestamp, err.Error())
+	}
 
-	e := <-listener
-	switch ev := e.(type) {
-	case cKafka.Error:
-		slog.Error("Failed to send notification to Kafka", "error", ev)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to send notification to Kafka",
-		})
-	case *cKafka.Message:
-		if ev.TopicPartition.Error != nil {
-			slog.Error("Failed to deliver message", "error", ev.TopicPartition.Error.Error())
-			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to save message to Kafka topic"})
-		} else {
-			c.Status(http.StatusCreated)
+	s.enrichTemplate(ctx, payload)
+
+	var key []byte
+	if cl.Key == config.KeyPayload {
+		key = []byte(payload.Key())
+	}
+	topic := s.topic(cl)
+	var msg []byte
+	switch cl.Format {
+	case config.FormatNotification:
+		msg, _ = json.Marshal(n)
+	case config.FormatRaw:
+		msg = n.Raw()
+	default:
+		var p *Problem
+		if msg, p = s.marshalOutput(ctx, payload, topic); p != nil {
+			return nil, p
 		}
+	}
+	r := kafka.Record{Topic: topic, Key: key, Timestamp: ts, Value: msg, Headers: formatHeaders(n)}
+	m, p := s.produce(ctx, "produce notification", r)
+	if p != nil {
+		return nil, p
+	}
+
+	// consent state
+	if d, ok := payload.(*notification.NotificationData); ok {
+		if s.config.Kafka.SnapshotTopic != "" {
+			if p = s.sendSnapshot(ctx, *n.Type, d, m.Timestamp, r.Headers); p != nil {
+				return nil, p
+			}
+		}
+		s.updateStore(ctx, *n.Type, *d, m.Timestamp)
+		s.scheduleExpiry(ctx, *n.Type, d, r)
+	}
+
+	return &m.TopicPartition, nil
+}
+
+// formatHeaders records the detected notification format
+func formatHeaders(n notification.Notification) map[string]string {
+	return map[string]string{notification.FormatHeader: n.Format()}
+}
+
+// topic returns the client's topic or the default output topic of its source
+func (s Server) topic(cl *client.Client) string {
+	if cl.Topic != "" {
+		return cl.Topic
+	}
+	st := s.current()
+	switch cl.Source {
+	case notification.EPIX:
+		return st.epixTopic
+	case notification.GPAS:
+		return st.gpasTopic
 	default:
-		slog.Error("Unexpected delivery response", "error", e)
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to deliver message to Kafka"})
+		return st.outputTopic
 	}
 }
 
-func (d NotificationData) SignerId() *SignerId {
-	if d.ConsentKey == nil || len(d.ConsentKey.SignerIds) == 0 {
+func validateUnknownTypes(policy string) error {
+	switch policy {
+	case "", config.UnknownTypesPass, config.UnknownTypesReject, config.UnknownTypesDeadLetter:
 		return nil
 	}
+	return fmt.Errorf("unknown policy: %s", policy)
+}
 
-	ids := d.ConsentKey.SignerIds
-	sort.Slice(d.ConsentKey.SignerIds, func(i, j int) bool {
-		return ids[i].OrderNumber < ids[j].OrderNumber
-	})
+// processUnknownType handles notification types without a registered payload
+// model according to the configured policy, they are passed if none is set
+func (s Server) processUnknownType(ctx context.Context, n notification.Notification, cl *client.Client, ts time.Time) (*cKafka.TopicPartition, *Problem) {
+	switch s.config.App.UnknownTypes {
+	case config.UnknownTypesPass, "":
+		slog.DebugContext(ctx, "Passing through notification of unknown type", "type", *n.Type)
+		return s.passUnknownType(ctx, n, cl, ts)
+	case config.UnknownTypesDeadLetter:
+		if s.config.Kafka.DeadLetterTopic == "" {
+			slog.ErrorContext(ctx, "No dead letter topic configured")
+			break
+		}
+		slog.WarnContext(ctx, "Sending notification of unknown type to dead letter topic", "type", *n.Type)
+		msg, _ := json.Marshal(n)
+		m, p := s.produce(ctx, "produce dead letter", kafka.Record{
+			Topic: s.config.Kafka.DeadLetterTopic, Timestamp: ts, Value: msg, Headers: formatHeaders(n),
+		})
+		if p != nil {
+			return nil, p
+		}
+		return &m.TopicPartition, nil
+	}
 
-	return &d.ConsentKey.SignerIds[0]
+	slog.ErrorContext(ctx, "Rejecting notification of unknown type", "type", *n.Type)
+	return nil, newProblem(UnknownType, *n.Type)
 }
 
-func (s Server) sendNotification(signerId *SignerId, created *string, data NotificationData, deliveryChan chan cKafka.Event) {
-	t := *data.ConsentKey.ConsentTemplateKey
-	key := hash(*t.DomainName, *t.Name, *t.Version, signerId.IdType, signerId.Id, *data.ConsentKey.ConsentDate)
-	loc, _ := time.LoadLocation("Europe/Berlin")
-	dt, err := time.ParseInLocation("2006-01-02T15:04:05", *created, loc)
-	if err != nil {
-		deliveryChan <- Error{"Unable to parse created date" + *created}
+// passUnknownType sends the data of an unknown type like a consent notification,
+// if it has a complete consent key. Other data can't be keyed or encrypted and
+// is sent unchanged, or rejected if encryption is enabled.
+func (s Server) passUnknownType(ctx context.Context, n notification.Notification, cl *client.Client, ts time.Time) (*cKafka.TopicPartition, *Problem) {
+	topic := s.topic(cl)
+	var key, msg []byte
+	d := &notification.NotificationData{}
+	if err := json.Unmarshal([]byte(*n.Data), d); err == nil && d.Validate() == nil {
+		if cl.Key == config.KeyPayload {
+			key = []byte(d.Key())
+		}
+		var p *Problem
+		if msg, p = s.marshalOutput(ctx, d, topic); p != nil {
+			return nil, p
+		}
+	} else if s.encryption != nil {
+		slog.ErrorContext(ctx, "Unable to encrypt notification of unknown type", "type", *n.Type)
+		return nil, newProblem(EncryptionFailed, "no consent data in notification of type "+*n.Type)
+	} else {
+		msg = []byte(*n.Data)
+	}
+
+	m, p := s.produce(ctx, "produce notification", kafka.Record{
+		Topic: topic, Key: key, Timestamp: ts, Value: msg, Headers: formatHeaders(n),
+	})
+	if p != nil {
+		return nil, p
+	}
+	return &m.TopicPartition, nil
+}
+
+// recordTimestamp selects the Kafka record timestamp according to the configured source.
+// The consent date is only available for consent notifications, otherwise the
+// created date is used.
+func (s Server) recordTimestamp(created, received time.Time, payload notification.Payload) (time.Time, error) {
+	switch s.config.App.Time.RecordTimestamp {
+	case config.RecordTimestampReceiveTime:
+		return received, nil
+	case config.RecordTimestampConsentDate:
+		if d, ok := payload.(*notification.NotificationData); ok {
+			return s.timestamps.Parse(*d.ConsentKey.ConsentDate)
+		}
 	}
-	msg, _ := json.Marshal(data)
+	return created, nil
+}
+
+func (s Server) produce(ctx context.Context, spanName string, r kafka.Record) (*cKafka.Message, *Problem) {
+	ctx, span := tracer().Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	defer s.monitor.track()()
+
+	listener := make(chan cKafka.Event, 1)
+	go s.producer.Send(ctx, r, listener)
+	return awaitDelivery(ctx, span, listener)
+}
 
-	go s.producer.Send([]byte(key), dt, msg, deliveryChan)
+func awaitDelivery(ctx context.Context, span trace.Span, listener chan cKafka.Event) (*cKafka.Message, *Problem) {
+	e := <-listener
+	switch ev := e.(type) {
+	case cKafka.Error:
+		recordError(span, ev)
+		slog.ErrorContext(ctx, "Failed to send notification to Kafka", "error", ev)
+		return nil, newProblem(KafkaUnavailable, ev.Error())
+	case *cKafka.Message:
+		addDeliveryEvent(span, ev)
+		if ev.TopicPartition.Error != nil {
+			recordError(span, ev.TopicPartition.Error)
+			slog.ErrorContext(ctx, "Failed to deliver message", "error", ev.TopicPartition.Error.Error())
+			return nil, newProblem(KafkaDeliveryFailed, ev.TopicPartition.Error.Error())
+		}
+		return ev, nil
+	default:
+		span.SetStatus(codes.Error, "Unexpected delivery response")
+		slog.ErrorContext(ctx, "Unexpected delivery response", "error", e)
+		return nil, newProblem(InternalError, "Unexpected delivery response")
+	}
 }
 
 func (s Server) checkHealth(c *gin.Context) {
-	if s.producer.IsHealthy() {
+	if s.producer.IsHealthy() && s.journalHealthy() {
 		c.JSON(http.StatusOK, gin.H{
 			"healthy": true,
 		})
@@ -206,20 +460,3 @@ func (s Server) checkHealth(c *gin.Context) {
 		})
 	}
 }
-
-func hash(values ...string) string {
-	h := crypto.SHA256.New()
-	for _, v := range values {
-		h.Write([]byte(v))
-	}
-	sum := h.Sum(nil)
-	return fmt.Sprintf("%x", sum)
-}
-
-type Error struct {
-	Error string
-}
-
-func (e Error) String() string {
-	return e.Error
-}
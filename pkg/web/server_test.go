@@ -2,13 +2,18 @@ package web
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
 	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -65,7 +70,7 @@ func TestNotificationHandler(t *testing.T) {
 				"data": "{}"
 			}
 		`},
-		{name: "notificationHandlerKafkaSendError", statusCode: 400, body: `
+		{name: "notificationHandlerKafkaSendError", statusCode: 503, body: `
 			{
 				"type": "GICS.AddConsent",
 				"clientId": "gICS_test",
@@ -104,7 +109,7 @@ type TestProducer struct {
 	kafkaResponse interface{}
 }
 
-func (p TestProducer) Send(_ []byte, _ time.Time, _ []byte, deliveryChan chan cKafka.Event) {
+func (p TestProducer) Send(_ context.Context, _ kafka.Record, deliveryChan chan cKafka.Event) {
 	switch v := p.kafkaResponse.(type) {
 	case cKafka.Message:
 		deliveryChan <- &v
@@ -139,32 +144,9 @@ func notificationHandler(t *testing.T, data TestCase) {
 
 	reqBody := []byte(data.body)
 
-	testRoute(t, s, "POST", "/notification", bytes.NewBuffer(reqBody), data.statusCode)
-}
-
-func TestSignerId(t *testing.T) {
-	expected := SignerId{
-		IdType:      "Test-ID",
-		Id:          "1",
-		OrderNumber: 1,
-	}
-
-	d := NotificationData{
-		ConsentKey: &ConsentKey{
-			SignerIds: []SignerId{
-				{
-					IdType:      "Test-ID",
-					Id:          "3",
-					OrderNumber: 3,
-				},
-				expected,
-			},
-		},
-	}
-
-	actual := *d.SignerId()
+	w := serve(s, "POST", "/notification", reqBody)
 
-	assert.Equal(t, expected, actual)
+	assert.Equal(t, data.statusCode, w.Code)
 }
 
 func TestCheckHealth(t *testing.T) {
@@ -204,22 +186,262 @@ func checkHealth(t *testing.T, data TestCase) {
 
 	s := Server{config: c, producer: data.producer}
 
-	testRoute(t, s, "GET", "/health", nil, data.statusCode)
+	w := serve(s, "GET", "/health", nil)
+
+	assert.Equal(t, data.statusCode, w.Code)
 }
 
-func testRoute(t *testing.T, s Server, method, endpoint string, body io.Reader, returnCode int) {
-	r := s.setupRouter()
+// testServer accepting notifications of test/test, without optional features
+func testServer(p kafka.Producer) Server {
+	return Server{
+		config: config.AppConfig{
+			App:   config.App{Http: config.Http{Auth: config.Auth{User: "test", Password: "test"}}},
+			Kafka: config.Kafka{OutputTopic: "notifications"},
+		},
+		producer: p,
+	}
+}
 
-	req, _ := http.NewRequest(method, endpoint, body)
+// serve a request with the server's credentials, unless changed by an option
+func serve(s Server, method, endpoint string, body []byte, opts ...func(*http.Request)) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, endpoint, bytes.NewReader(body))
 	req.SetBasicAuth(s.config.App.Http.Auth.User, s.config.App.Http.Auth.Password)
+	for _, opt := range opts {
+		opt(req)
+	}
 	w := httptest.NewRecorder()
 
-	r.ServeHTTP(w, req)
+	s.setupRouter().ServeHTTP(w, req)
+	return w
+}
+
+func withAuth(user, password string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.SetBasicAuth(user, password)
+	}
+}
+
+func withHeader(key, value string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
+type SourceTestCase struct {
+	name          string
+	body          string
+	statusCode    int
+	expectedTopic string
+}
+
+func TestProcessNotification_Sources(t *testing.T) {
+	cases := []SourceTestCase{
+		{name: "gics", body: validNotification, statusCode: http.StatusCreated, expectedTopic: "notifications"},
+		{name: "epix", statusCode: http.StatusCreated, expectedTopic: "epix",
+			body: `{"type":"EPIX.AddPerson","clientId":"E-PIX_Web","createdAt":"2023-06-05T12:09:10","data":"{\"domainName\":\"MII\",\"mpiId\":\"1001\"}"}`},
+		{name: "gpas", statusCode: http.StatusCreated, expectedTopic: "gpas",
+			body: `{"type":"GPAS.GetOrCreatePseudonymFor","clientId":"gPAS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"domainName\":\"MII\",\"originalValue\":\"1001\",\"pseudonym\":\"psn-1\"}"}`},
+		{name: "invalidEpixData", statusCode: http.StatusBadRequest,
+			body: `{"type":"EPIX.AddPerson","clientId":"E-PIX_Web","createdAt":"2023-06-05T12:09:10","data":"{\"domainName\":\"MII\"}"}`},
+		{name: "typeOfOtherSource", statusCode: http.StatusBadRequest,
+			body: `{"type":"GICS.AddConsent","clientId":"gPAS_Web","createdAt":"2023-06-05T12:09:10","data":"{}"}`},
+		{name: "prefixNotAtStart", statusCode: http.StatusBadRequest,
+			body: `{"type":"GICS.AddConsent","clientId":"not_gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{}"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &RecordingProducer{}
+			s := testServer(p)
+			s.config.App.UnknownTypes = config.UnknownTypesReject
+			s.config.Kafka.EpixTopic = "epix"
+			s.config.Kafka.GpasTopic = "gpas"
+
+			w := serve(s, "POST", "/notification", []byte(c.body))
 
-	assert.Equal(t, returnCode, w.Code)
+			assert.Equal(t, c.statusCode, w.Code)
+			if c.expectedTopic == "" {
+				assert.Empty(t, p.records)
+				return
+			}
+			if assert.Len(t, p.records, 1) {
+				assert.Equal(t, c.expectedTopic, p.records[0].Topic)
+				assert.NotEmpty(t, p.records[0].Key)
+			}
+		})
+	}
 }
 
-func TestError_String(t *testing.T) {
-	e := Error{"test"}
-	assert.Equal(t, e.String(), "test")
+func TestProcessNotification_Clients(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.App.Clients = []config.Client{
+		{Id: "gICS_Web", User: "other"},
+		{Id: "Consent-Web", Source: "gICS", Topic: "consents", Key: config.KeyNone, Format: config.FormatNotification},
+	}
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = serve(s, "POST", "/notification", []byte(strings.Replace(validNotification, "gICS_Web", "gICS_Other", 1)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, p.records)
+
+	custom := strings.Replace(validNotification, "gICS_Web", "Consent-Web", 1)
+	w = serve(s, "POST", "/notification", []byte(custom))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 1) {
+		assert.Equal(t, "consents", p.records[0].Topic)
+		assert.Nil(t, p.records[0].Key)
+		assert.JSONEq(t, custom, string(p.records[0].Value))
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	actual := accounts(config.Auth{User: "test", Password: "test", Accounts: []config.Account{{User: "other", Password: "secret"}}})
+
+	assert.Equal(t, gin.Accounts{"test": "test", "other": "secret"}, actual)
+}
+
+func TestAccounts_SkipsEmpty(t *testing.T) {
+	actual := accounts(config.Auth{Accounts: []config.Account{{User: "other"}, {Password: "secret"}, {User: "test", Password: "test"}}})
+
+	assert.Equal(t, gin.Accounts{"test": "test"}, actual)
+}
+
+type RecordTimestampTestCase struct {
+	name     string
+	source   string
+	body     string
+	expected time.Time
+}
+
+func TestProcessNotification_RecordTimestamp(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	template := `{"type":"GICS.DeleteConsentTemplate","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"consentTemplateKey\":{\"domainName\":\"MII\",\"name\":\"Patienteneinwilligung MII\",\"version\":\"1.6.d\"}}"}`
+
+	cases := []RecordTimestampTestCase{
+		{name: "default", body: validNotification, expected: time.Date(2023, 6, 5, 12, 9, 10, 0, berlin)},
+		{name: "createdAt", source: config.RecordTimestampCreatedAt, body: validNotification, expected: time.Date(2023, 6, 5, 12, 9, 10, 0, berlin)},
+		{name: "consentDate", source: config.RecordTimestampConsentDate, body: validNotification, expected: time.Date(2023, 5, 2, 1, 57, 27, 0, berlin)},
+		{name: "consentDateWithoutConsent", source: config.RecordTimestampConsentDate, body: template, expected: time.Date(2023, 6, 5, 12, 9, 10, 0, berlin)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &RecordingProducer{}
+			s := testServer(p)
+			s.config.App.Time.RecordTimestamp = c.source
+
+			w := serve(s, "POST", "/notification", []byte(c.body))
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			if assert.Len(t, p.records, 1) {
+				assert.True(t, c.expected.Equal(p.records[0].Timestamp), "expected %s, got %s", c.expected, p.records[0].Timestamp)
+			}
+		})
+	}
+}
+
+func TestProcessNotification_ReceiveTime(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.App.Time.RecordTimestamp = config.RecordTimestampReceiveTime
+
+	before := time.Now()
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.WithinRange(t, p.records[0].Timestamp, before, time.Now())
+}
+
+func TestProcessNotification_InvalidCreatedAt(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+
+	w := serve(s, "POST", "/notification", []byte(strings.Replace(validNotification, "2023-06-05T12:09:10", "05.06.2023", 1)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(InvalidTimestamp))
+	// nothing is sent with a zero timestamp
+	assert.Empty(t, p.records)
+}
+
+func TestNewTimestampParser(t *testing.T) {
+	_, err := newTimestampParser(config.Time{RecordTimestamp: "test"})
+	assert.Error(t, err)
+
+	_, err = newTimestampParser(config.Time{Zone: "Europe/Marburg"})
+	assert.Error(t, err)
+
+	p, err := newTimestampParser(config.Time{Zone: "UTC", RecordTimestamp: config.RecordTimestampConsentDate})
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, p.Location())
+}
+
+func TestProcessNotification_RawFormat(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.Kafka.SnapshotTopic = "snapshots"
+	s.config.App.OutputFormat = config.FormatRaw
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 2) {
+		var n notification.Notification
+		_ = json.Unmarshal([]byte(validNotification), &n)
+		assert.Equal(t, string(n.Raw()), string(p.records[0].Value))
+		assert.NotEmpty(t, p.records[0].Key)
+		// snapshots keep their format
+		assert.Contains(t, string(p.records[1].Value), `"notificationType":"GICS.AddConsent"`)
+	}
+}
+
+func TestProcessNotification_ObjectData(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+
+	body := `{"type":"GICS.AddConsent","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":{"consentKey":{"consentTemplateKey":{"domainName":"MII","name":"Patienteneinwilligung MII","version":"1.6.d"},"signerIds":[{"idType":"test","id":"1","orderNumber":1}],"consentDate":"2023-05-02 01:57:27"}}}`
+	w := serve(s, "POST", "/notification", []byte(body))
+	_ = serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 2) {
+		// same output for both data shapes
+		assert.Equal(t, p.records[1].Value, p.records[0].Value)
+		assert.Equal(t, map[string]string{notification.FormatHeader: notification.FormatObject}, p.records[0].Headers)
+		assert.Equal(t, map[string]string{notification.FormatHeader: notification.FormatString}, p.records[1].Headers)
+	}
+}
+
+func TestProcessNotification_InvalidDataShape(t *testing.T) {
+	w := serve(testServer(&RecordingProducer{}), "POST", "/notification",
+		[]byte(`{"type":"GICS.AddConsent","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":42}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(InvalidJson))
+}
+
+func TestValidate(t *testing.T) {
+	c := config.AppConfig{App: config.App{Time: config.Time{Zone: "Europe/Berlin"}, Http: config.Http{
+		Auth:  config.Auth{User: "test", Password: "test"},
+		Batch: config.Batch{Concurrency: 50, MaxSize: 1000, MaxBodySize: 10},
+	}}}
+	assert.NoError(t, Validate(c))
+
+	c.App.OutputFormat = "xml"
+	c.App.Time.Zone = "Mars/Olympus"
+	c.Kafka.NormalizeDates = []config.DateNormalization{{Topic: "test", Format: "local"}}
+	c.Expiry = config.Expiry{Enabled: true}
+	c.App.UnknownTypes = "drop"
+	c.App.Http.Batch.Concurrency = maxBatchConcurrency + 1
+
+	err := Validate(c)
+	assert.ErrorContains(t, err, "invalid client configuration")
+	assert.ErrorContains(t, err, "invalid time configuration")
+	assert.ErrorContains(t, err, "invalid date normalization configuration")
+	assert.ErrorContains(t, err, "invalid expiry configuration")
+	assert.ErrorContains(t, err, "invalid unknown types configuration")
+	assert.ErrorContains(t, err, "invalid batch configuration")
 }
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"context"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// sendSnapshot sends the consent's current state to the compacted snapshot
+// topic, keyed by consent, or a tombstone if the consent was deleted or expired.
+// The headers are those of the consent's record.
+func (s Server) sendSnapshot(ctx context.Context, notificationType string, d *notification.NotificationData, timestamp time.Time, headers map[string]string) *Problem {
+	ctx, span := tracer().Start(ctx, "produce snapshot", trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	var value []byte
+	if !removesConsent(notificationType) {
+		snapshot := notification.NewSnapshot(notificationType, *d, timestamp)
+		var p *Problem
+		if value, p = s.marshalOutput(ctx, &snapshot, s.config.Kafka.SnapshotTopic); p != nil {
+			recordError(span, p)
+			return p
+		}
+	}
+
+	defer s.monitor.track()()
+	listener := make(chan cKafka.Event, 1)
+	go s.producer.Send(ctx, kafka.Record{
+		Topic:     s.config.Kafka.SnapshotTopic,
+		Key:       []byte(d.Key()),
+		Timestamp: timestamp,
+		Value:     value,
+		Headers:   headers,
+	}, listener)
+
+	_, p := awaitDelivery(ctx, span, listener)
+	return p
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gics-to-kafka/pkg/kafka"
+	"gics-to-kafka/pkg/notification"
+	"gics-to-kafka/pkg/store"
+	"gics-to-kafka/pkg/timestamp"
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type RecordingProducer struct {
+	TestProducer
+	mu      sync.Mutex
+	records []kafka.Record
+	// topics failing to deliver
+	failing map[string]bool
+}
+
+func (p *RecordingProducer) Send(_ context.Context, r kafka.Record, deliveryChan chan cKafka.Event) {
+	p.mu.Lock()
+	p.records = append(p.records, r)
+	p.mu.Unlock()
+
+	m := &cKafka.Message{TopicPartition: cKafka.TopicPartition{Topic: &r.Topic}}
+	if p.failing[r.Topic] {
+		m.TopicPartition.Error = errors.New("failed to save message")
+	}
+	deliveryChan <- m
+}
+
+func TestSendSnapshot(t *testing.T) {
+	st, _ := store.Open(filepath.Join(t.TempDir(), "consents.db"), timestamp.Default.Parse)
+	t.Cleanup(func() { _ = st.Close() })
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.Kafka.SnapshotTopic = "snapshots"
+	s.store = st
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 2) {
+		assert.Equal(t, "notifications", p.records[0].Topic)
+		assert.Equal(t, "snapshots", p.records[1].Topic)
+		assert.Equal(t, p.records[0].Key, p.records[1].Key)
+		assert.Equal(t, p.records[0].Headers, p.records[1].Headers)
+
+		var actual notification.ConsentSnapshot
+		_ = json.Unmarshal(p.records[1].Value, &actual)
+		assert.Equal(t, "GICS.AddConsent", actual.NotificationType)
+		assert.Equal(t, "2023-05-02 01:57:27", *actual.ConsentKey.ConsentDate)
+	}
+	consents, _ := st.Consents("test", "1")
+	assert.Len(t, consents, 1)
+
+	// delete consent
+	w = serve(s, "POST", "/notification",
+		[]byte(strings.Replace(validNotification, "GICS.AddConsent", notification.DeleteConsentType, 1)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 4) {
+		assert.Equal(t, "snapshots", p.records[3].Topic)
+		assert.Equal(t, p.records[0].Key, p.records[3].Key)
+		assert.Nil(t, p.records[3].Value)
+	}
+	consents, _ = st.Consents("test", "1")
+	assert.Empty(t, consents)
+}
+
+func TestSendSnapshot_Disabled(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, p.records, 1)
+}
+
+func TestSendSnapshot_DeliveryFailed(t *testing.T) {
+	p := &RecordingProducer{failing: map[string]bool{"snapshots": true}}
+	s := testServer(p)
+	s.config.Kafka.SnapshotTopic = "snapshots"
+
+	w := serve(s, "POST", "/notification", []byte(validNotification))
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "gics-to-kafka/pkg/web"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// endSpan ends the span and marks it as failed if err is not nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		recordError(span, err)
+	}
+	span.End()
+}
+
+func addDeliveryEvent(span trace.Span, m *cKafka.Message) {
+	attrs := []attribute.KeyValue{
+		attribute.Int("messaging.kafka.destination.partition", int(m.TopicPartition.Partition)),
+		attribute.Int64("messaging.kafka.message.offset", int64(m.TopicPartition.Offset)),
+	}
+	if m.TopicPartition.Topic != nil {
+		attrs = append(attrs, attribute.String("messaging.destination.name", *m.TopicPartition.Topic))
+	}
+	if m.TopicPartition.Error != nil {
+		attrs = append(attrs, attribute.String("error", m.TopicPartition.Error.Error()))
+	}
+
+	span.AddEvent("delivery report", trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	cKafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net/http"
+	"testing"
+)
+
+func TestNotificationTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	s := testServer(TestProducer{kafkaResponse: cKafka.Message{
+		TopicPartition: cKafka.TopicPartition{Partition: 1, Offset: 42},
+	}})
+	s.config.App.Name = "test"
+	w := serve(s, "POST", "/notification", []byte(validNotification),
+		withHeader("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	server, ok := spans["/notification"]
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+
+	for _, name := range []string{"bind notification", "parse notification data", "produce notification"} {
+		child, ok := spans[name]
+		if assert.True(t, ok, name) {
+			assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID(), name)
+		}
+	}
+
+	produce := spans["produce notification"]
+	if assert.Len(t, produce.Events, 1) {
+		assert.Equal(t, "delivery report", produce.Events[0].Name)
+	}
+}
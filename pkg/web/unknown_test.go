@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/json"
+	"gics-to-kafka/pkg/config"
+	"gics-to-kafka/pkg/notification"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const unknownNotification = `{"type":"GICS.Foo","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"foo\":\"bar\"}"}`
+
+type UnknownTypeTestCase struct {
+	name            string
+	policy          string
+	deadLetterTopic string
+	statusCode      int
+	expectedTopic   string
+}
+
+func TestProcessUnknownType(t *testing.T) {
+	cases := []UnknownTypeTestCase{
+		{name: "pass", policy: config.UnknownTypesPass, statusCode: http.StatusCreated, expectedTopic: "notifications"},
+		{name: "default", policy: "", statusCode: http.StatusCreated, expectedTopic: "notifications"},
+		{name: "reject", policy: config.UnknownTypesReject, statusCode: http.StatusBadRequest},
+		{name: "deadLetter", policy: config.UnknownTypesDeadLetter, deadLetterTopic: "dlq",
+			statusCode: http.StatusCreated, expectedTopic: "dlq"},
+		{name: "deadLetterWithoutTopic", policy: config.UnknownTypesDeadLetter, statusCode: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &RecordingProducer{}
+			s := testServer(p)
+			s.config.App.UnknownTypes = c.policy
+			s.config.Kafka.DeadLetterTopic = c.deadLetterTopic
+
+			w := serve(s, "POST", "/notification", []byte(unknownNotification))
+
+			assert.Equal(t, c.statusCode, w.Code)
+			if c.expectedTopic == "" {
+				assert.Empty(t, p.records)
+				return
+			}
+			if assert.Len(t, p.records, 1) {
+				assert.Equal(t, c.expectedTopic, p.records[0].Topic)
+				assert.Nil(t, p.records[0].Key)
+				assert.Equal(t, map[string]string{notification.FormatHeader: notification.FormatString}, p.records[0].Headers)
+			}
+		})
+	}
+}
+
+func TestProcessUnknownType_Values(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.config.App.UnknownTypes = config.UnknownTypesPass
+
+	_ = serve(s, "POST", "/notification", []byte(unknownNotification))
+	assert.JSONEq(t, `{"foo":"bar"}`, string(p.records[0].Value))
+
+	s.config.App.UnknownTypes = config.UnknownTypesDeadLetter
+	s.config.Kafka.DeadLetterTopic = "dlq"
+
+	_ = serve(s, "POST", "/notification", []byte(unknownNotification))
+
+	assert.Equal(t, map[string]string{notification.FormatHeader: notification.FormatString}, p.records[1].Headers)
+	var actual notification.Notification
+	_ = json.Unmarshal(p.records[1].Value, &actual)
+	assert.Equal(t, "GICS.Foo", *actual.Type)
+	assert.Equal(t, `{"foo":"bar"}`, *actual.Data)
+}
+
+func TestProcessNotification_TemplatePayload(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+
+	w := serve(s, "POST", "/notification", []byte(`{"type":"GICS.UpdateConsentTemplate","clientId":"gICS_Web","createdAt":"2023-06-05T12:09:10","data":"{\"consentTemplateKey\":{\"domainName\":\"MII\",\"name\":\"Patienteneinwilligung MII\",\"version\":\"1.6.d\"}}"}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	// template changes are not sent to the consent snapshot topic
+	if assert.Len(t, p.records, 1) {
+		assert.Equal(t, "notifications", p.records[0].Topic)
+	}
+}
+
+func TestProcessUnknownType_ConsentData(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.encryption = testKMS(t)
+
+	w := serve(s, "POST", "/notification", []byte(strings.Replace(validNotification, "GICS.AddConsent", "GICS.Foo", 1)))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.Len(t, p.records, 1) {
+		assert.Equal(t, "notifications", p.records[0].Topic)
+		assert.NotEmpty(t, p.records[0].Key)
+		assert.NotContains(t, string(p.records[0].Value), `"id":"1"`)
+		assert.Contains(t, string(p.records[0].Value), `"encryption":`)
+	}
+}
+
+func TestProcessUnknownType_EncryptionWithoutConsentData(t *testing.T) {
+	p := &RecordingProducer{}
+	s := testServer(p)
+	s.encryption = testKMS(t)
+
+	w := serve(s, "POST", "/notification", []byte(unknownNotification))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), string(EncryptionFailed))
+	assert.Empty(t, p.records)
+}